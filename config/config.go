@@ -0,0 +1,195 @@
+// Package config loads the server settings from defaults, an optional TOML
+// file, SSH_BATTLE_* environment variables and command line flags, in that
+// order of precedence.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+type Config struct {
+	Server   Server   `toml:"server"`
+	Database Database `toml:"database"`
+	Game     Game     `toml:"game"`
+}
+
+type Server struct {
+	Addr        string `toml:"addr"`
+	HostKeyPath string `toml:"host_key"`
+	// Shown to anyone trying to log in as root before they get disconnected
+	RootBanner  string `toml:"root_banner"`
+	AllowGuests bool   `toml:"allow_guests"`
+	// Usernames allowed to run admin commands like :reload
+	Admins []string `toml:"admins"`
+	// How long running matches get to finish after SIGINT/SIGTERM
+	ShutdownGrace time.Duration `toml:"shutdown_grace"`
+}
+
+type Database struct {
+	// "sqlite" or "postgres"
+	Driver string `toml:"driver"`
+	// SQLite database file
+	Path string `toml:"path"`
+	// PostgreSQL connection string
+	DSN string `toml:"dsn"`
+}
+
+type Game struct {
+	// English word list
+	WordsFile string `toml:"words_file"`
+	// Word lists for other languages, one <language code>.txt each
+	WordsDir      string        `toml:"words_dir"`
+	DuosTimeLimit time.Duration `toml:"duos_time_limit"`
+}
+
+func Default() Config {
+	return Config{
+		Server: Server{
+			Addr:        ":2222",
+			HostKeyPath: "host_key.pem",
+			RootBanner:  "Can't login as root to avoid bots from scanning this session. Try running something like \"ssh Username@quinver.dev -p 2222\"...",
+			// Enough for a full duos round including the countdown
+			ShutdownGrace: 75 * time.Second,
+		},
+		Database: Database{
+			Driver: "sqlite",
+			Path:   "data/game.db",
+		},
+		Game: Game{
+			WordsFile:     "data/words.txt",
+			WordsDir:      "data/words",
+			DuosTimeLimit: 60 * time.Second,
+		},
+	}
+}
+
+type binding struct {
+	flag  string
+	env   string
+	usage string
+	value flag.Value
+	bool  bool
+}
+
+func bindings(c *Config) []binding {
+	return []binding{
+		{"addr", "SSH_BATTLE_ADDR", "address the SSH server listens on", (*stringValue)(&c.Server.Addr), false},
+		{"host-key", "SSH_BATTLE_HOST_KEY", "path to the host key, generated if missing", (*stringValue)(&c.Server.HostKeyPath), false},
+		{"root-banner", "SSH_BATTLE_ROOT_BANNER", "message shown to root login attempts", (*stringValue)(&c.Server.RootBanner), false},
+		{"allow-guests", "SSH_BATTLE_ALLOW_GUESTS", "let unregistered names play as guests without saving scores", (*boolValue)(&c.Server.AllowGuests), true},
+		{"admins", "SSH_BATTLE_ADMINS", "comma separated usernames allowed to run admin commands", (*listValue)(&c.Server.Admins), false},
+		{"shutdown-grace", "SSH_BATTLE_SHUTDOWN_GRACE", "how long running matches get to finish on shutdown, e.g. 75s", (*durationValue)(&c.Server.ShutdownGrace), false},
+		{"db-driver", "SSH_BATTLE_DB_DRIVER", "database driver, sqlite or postgres", (*stringValue)(&c.Database.Driver), false},
+		{"db", "SSH_BATTLE_DB", "path to the SQLite database", (*stringValue)(&c.Database.Path), false},
+		{"db-dsn", "SSH_BATTLE_DB_DSN", "PostgreSQL connection string", (*stringValue)(&c.Database.DSN), false},
+		{"words", "SSH_BATTLE_WORDS", "English word list used to seed the words table", (*stringValue)(&c.Game.WordsFile), false},
+		{"words-dir", "SSH_BATTLE_WORDS_DIR", "directory of <language>.txt word lists for other languages", (*stringValue)(&c.Game.WordsDir), false},
+		{"duos-time-limit", "SSH_BATTLE_DUOS_TIME_LIMIT", "time limit for a duos round, e.g. 60s", (*durationValue)(&c.Game.DuosTimeLimit), false},
+	}
+}
+
+// Load builds the config from args (without the program name). The file is
+// given with -config or SSH_BATTLE_CONFIG.
+func Load(args []string) (Config, error) {
+	cfg := Default()
+	binds := bindings(&cfg)
+
+	fs := flag.NewFlagSet("ssh-battle", flag.ContinueOnError)
+	path := fs.String("config", os.Getenv("SSH_BATTLE_CONFIG"), "path to a TOML config file")
+
+	// Flags win over the file and env, so hold on to them until those are applied
+	var flagValues []func() error
+	for _, b := range binds {
+		set := func(v string) error {
+			flagValues = append(flagValues, func() error {
+				if err := b.value.Set(v); err != nil {
+					return fmt.Errorf("-%s: %w", b.flag, err)
+				}
+				return nil
+			})
+			return nil
+		}
+		usage := fmt.Sprintf("%s (env %s)", b.usage, b.env)
+		if b.bool {
+			fs.BoolFunc(b.flag, usage, set)
+		} else {
+			fs.Func(b.flag, usage, set)
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	if *path != "" {
+		if _, err := toml.DecodeFile(*path, &cfg); err != nil {
+			return cfg, fmt.Errorf("config %s: %w", *path, err)
+		}
+	}
+
+	for _, b := range binds {
+		if v, ok := os.LookupEnv(b.env); ok {
+			if err := b.value.Set(v); err != nil {
+				return cfg, fmt.Errorf("%s: %w", b.env, err)
+			}
+		}
+	}
+
+	for _, apply := range flagValues {
+		if err := apply(); err != nil {
+			return cfg, err
+		}
+	}
+
+	return cfg, nil
+}
+
+type stringValue string
+
+func (s *stringValue) Set(v string) error { *s = stringValue(v); return nil }
+func (s *stringValue) String() string     { return string(*s) }
+
+// listValue is a comma separated list.
+type listValue []string
+
+func (l *listValue) Set(v string) error {
+	*l = nil
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+func (l *listValue) String() string { return strings.Join(*l, ",") }
+
+type boolValue bool
+
+func (b *boolValue) Set(v string) error {
+	parsed, err := strconv.ParseBool(v)
+	if err != nil {
+		return err
+	}
+	*b = boolValue(parsed)
+	return nil
+}
+func (b *boolValue) String() string { return strconv.FormatBool(bool(*b)) }
+
+type durationValue time.Duration
+
+func (d *durationValue) Set(v string) error {
+	parsed, err := time.ParseDuration(v)
+	if err != nil {
+		return err
+	}
+	*d = durationValue(parsed)
+	return nil
+}
+func (d *durationValue) String() string { return time.Duration(*d).String() }
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv unsets every SSH_BATTLE_* variable Load reads for the rest of t.
+func clearEnv(t *testing.T) {
+	t.Helper()
+	envs := []string{"SSH_BATTLE_CONFIG"}
+	for _, b := range bindings(&Config{}) {
+		envs = append(envs, b.env)
+	}
+	for _, env := range envs {
+		// Setenv restores the old value when t ends
+		t.Setenv(env, "")
+		os.Unsetenv(env)
+	}
+}
+
+func writeFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ssh-battle.toml")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const testFile = `
+[server]
+addr = ":3000"
+admins = ["alice"]
+shutdown_grace = "30s"
+
+[database]
+driver = "postgres"
+dsn = "postgres://file"
+
+[game]
+duos_time_limit = "90s"
+`
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name string
+		// Written to a temp file passed with -config when not empty
+		file string
+		env  map[string]string
+		args []string
+		want func(*Config)
+	}{
+		{"defaults", "", nil, nil, func(c *Config) {}},
+		{
+			"file over defaults", testFile, nil, nil,
+			func(c *Config) {
+				c.Server.Addr = ":3000"
+				c.Server.Admins = []string{"alice"}
+				c.Server.ShutdownGrace = 30 * time.Second
+				c.Database.Driver = "postgres"
+				c.Database.DSN = "postgres://file"
+				c.Game.DuosTimeLimit = 90 * time.Second
+			},
+		},
+		{
+			"env over file", testFile,
+			map[string]string{
+				"SSH_BATTLE_ADDR":            ":4000",
+				"SSH_BATTLE_ADMINS":          "bob, carol,",
+				"SSH_BATTLE_ALLOW_GUESTS":    "true",
+				"SSH_BATTLE_DUOS_TIME_LIMIT": "2m",
+			},
+			nil,
+			func(c *Config) {
+				c.Server.Addr = ":4000"
+				c.Server.Admins = []string{"bob", "carol"}
+				c.Server.AllowGuests = true
+				c.Server.ShutdownGrace = 30 * time.Second
+				c.Database.Driver = "postgres"
+				c.Database.DSN = "postgres://file"
+				c.Game.DuosTimeLimit = 2 * time.Minute
+			},
+		},
+		{
+			"flags over env and file", testFile,
+			map[string]string{
+				"SSH_BATTLE_ADDR":         ":4000",
+				"SSH_BATTLE_DB_DSN":       "postgres://env",
+				"SSH_BATTLE_ALLOW_GUESTS": "true",
+			},
+			[]string{"-addr", ":5000", "-db-driver=sqlite", "-allow-guests=false", "-shutdown-grace", "5s"},
+			func(c *Config) {
+				c.Server.Addr = ":5000"
+				c.Server.Admins = []string{"alice"}
+				c.Server.ShutdownGrace = 5 * time.Second
+				c.Database.Driver = "sqlite"
+				c.Database.DSN = "postgres://env"
+				c.Game.DuosTimeLimit = 90 * time.Second
+			},
+		},
+		{
+			"flags over env without a file", "",
+			map[string]string{"SSH_BATTLE_DB": "env.db", "SSH_BATTLE_WORDS_DIR": "env/words"},
+			[]string{"-db", "flag.db", "-allow-guests"},
+			func(c *Config) {
+				c.Server.AllowGuests = true
+				c.Database.Path = "flag.db"
+				c.Game.WordsDir = "env/words"
+			},
+		},
+		{
+			"last flag wins", "", nil,
+			[]string{"-words", "a.txt", "-words", "b.txt"},
+			func(c *Config) { c.Game.WordsFile = "b.txt" },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", writeFile(t, tt.file)}, args...)
+			}
+
+			got, err := Load(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := Default()
+			tt.want(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Load(%q) =\n%+v\nwant\n%+v", args, got, want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("SSH_BATTLE_CONFIG", writeFile(t, testFile))
+
+	got, err := Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Server.Addr != ":3000" {
+		t.Errorf("addr %q, want the file's :3000", got.Server.Addr)
+	}
+
+	// -config beats SSH_BATTLE_CONFIG
+	got, err = Load([]string{"-config", writeFile(t, `server.addr = ":6000"`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Server.Addr != ":6000" {
+		t.Errorf("addr %q, want the -config file's :6000", got.Server.Addr)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		args []string
+		// Part of the error message
+		want string
+	}{
+		{"bad env duration", "", map[string]string{"SSH_BATTLE_SHUTDOWN_GRACE": "soon"}, nil, "SSH_BATTLE_SHUTDOWN_GRACE"},
+		{"bad env bool", "", map[string]string{"SSH_BATTLE_ALLOW_GUESTS": "maybe"}, nil, "SSH_BATTLE_ALLOW_GUESTS"},
+		{"bad flag duration", "", nil, []string{"-duos-time-limit", "long"}, "-duos-time-limit"},
+		{"unknown flag", "", nil, []string{"-nope"}, "-nope"},
+		{"bad file", "[server\n", nil, nil, "config "},
+		{"missing file", "", nil, []string{"-config", "does/not/exist.toml"}, "does/not/exist.toml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", writeFile(t, tt.file)}, args...)
+			}
+
+			_, err := Load(args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load(%q) = %v, want an error about %s", args, err, tt.want)
+			}
+		})
+	}
+}
@@ -2,95 +2,86 @@ package data
 
 import (
 	"bufio"
-	"database/sql"
+	"errors"
+	"io/fs"
 	"log"
 	"os"
-
-	_ "github.com/mattn/go-sqlite3"
+	"path/filepath"
+	"ssh-battle/config"
+	"strings"
 )
 
-var DB *sql.DB
-
-func InitDB() {
-
-	err := os.MkdirAll("data", 0755)
-	// Make sure the directory exists
-	if err != nil {
-		log.Fatal("failed to create data directory:", err)
+// InitDB opens the database and brings its schema up to date.
+func InitDB(cfg config.Database) {
+	if err := OpenDB(cfg); err != nil {
+		log.Fatal(err)
 	}
 
-	var err2 error
-	DB, err2 = sql.Open("sqlite3", "data/game.db")
-	if err2 != nil {
-		log.Fatal(err2)
+	if _, err := Repo.Migrate(); err != nil {
+		log.Fatal("failed to migrate database:", err)
 	}
+}
 
-	sqlStmt := `
-	CREATE TABLE IF NOT EXISTS words (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		word TEXT NOT NULL UNIQUE
-	);
-	CREATE TABLE IF NOT EXISTS players (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		username TEXT UNIQUE COLLATE NOCASE NOT NULL,
-		password_hash TEXT
-	);
-
-	CREATE TABLE IF NOT EXISTS scores (
-	  	id INTEGER PRIMARY KEY AUTOINCREMENT,
-	  	player_id INTEGER NOT NULL,
-	  	accuracy REAL,
-	  	wpm REAL,
-		tp REAL,
-	  	duration INTEGER,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP, 
-		FOREIGN KEY(player_id) REFERENCES players(id) ON DELETE CASCADE
-	);
-
-
-`
-	_, err = DB.Exec(sqlStmt)
+// OpenDB opens the database without touching its schema.
+func OpenDB(cfg config.Database) error {
+	repo, err := Open(cfg)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	Repo = repo
+	return nil
 }
 
 func CloseDB() {
-	DB.Close()
+	Repo.Close()
 }
 
-func SeedWords(filename string) error {
+// SeedWords adds the words in filename, one per line, to the language's list.
+func SeedWords(language, filename string) error {
 	file, err := os.Open(filename)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
+	var words []string
 	scanner := bufio.NewScanner(file)
-	tx, err := DB.Begin()
-	if err != nil {
+	for scanner.Scan() {
+		// Blank lines and comments would end up in sentences otherwise
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		words = append(words, word)
+	}
+
+	if err := scanner.Err(); err != nil {
 		return err
 	}
 
-	stmt, err := tx.Prepare("INSERT OR IGNORE INTO words (word) VALUES (?)")
+	return Repo.Words().Add(language, words)
+}
+
+// SeedLanguages seeds every <language>.txt in dir, e.g. nl.txt for Dutch. A
+// missing dir only means there are no extra languages.
+func SeedLanguages(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
 
-	for scanner.Scan() {
-		word := scanner.Text()
-		if _, err := stmt.Exec(word); err != nil {
-			tx.Rollback()
+	for _, entry := range entries {
+		language, ok := strings.CutSuffix(entry.Name(), ".txt")
+		if entry.IsDir() || !ok {
+			continue
+		}
+		if err := SeedWords(strings.ToLower(language), filepath.Join(dir, entry.Name())); err != nil {
 			return err
 		}
+		log.Printf("Seeded %s words from %s", language, entry.Name())
 	}
-
-	if err := scanner.Err(); err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	return tx.Commit()
+	return nil
 }
-
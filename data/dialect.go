@@ -0,0 +1,46 @@
+package data
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect covers the differences between the supported databases. Queries are
+// written with ? placeholders and rebound before they run.
+type Dialect struct {
+	Name   string
+	driver string
+	// Numbered placeholders ($1, $2, ...) instead of ?
+	numbered bool
+}
+
+var (
+	SQLite   = Dialect{Name: "sqlite", driver: "sqlite3"}
+	Postgres = Dialect{Name: "postgres", driver: "postgres", numbered: true}
+)
+
+// Rebind rewrites the ? placeholders in query for the dialect.
+func (d Dialect) Rebind(query string) string {
+	if !d.numbered {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+
+	n := 0
+	inString := false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			inString = !inString
+		case r == '?' && !inString:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,154 @@
+package data
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migrations live in migrations/<dialect>/ named <version>_<name>.sql and are
+// applied in version order. Every version needs a file for each dialect.
+// Never edit one that has shipped, add a new one instead.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+func loadMigrations(dialect Dialect) ([]Migration, error) {
+	dir := "migrations/" + dialect.Name
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	seen := make(map[int]string)
+	for _, entry := range entries {
+		file := entry.Name()
+		base := strings.TrimSuffix(file, ".sql")
+		versionStr, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected <version>_<name>.sql", file)
+		}
+
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: bad version: %w", file, err)
+		}
+		if other, dup := seen[version]; dup {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", other, file, version)
+		}
+		seen[version] = file
+
+		body, err := migrationFiles.ReadFile(dir + "/" + file)
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, Migration{Version: version, Name: name, SQL: string(body)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+func (r *sqlRepository) ensureSchemaVersion() error {
+	_, err := r.conn().exec(`
+	CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	);`)
+	return err
+}
+
+// MigrationStatuses lists every known migration and when it was applied, nil if pending.
+func (r *sqlRepository) MigrationStatuses() ([]MigrationStatus, error) {
+	if err := r.ensureSchemaVersion(); err != nil {
+		return nil, err
+	}
+
+	migrations, err := loadMigrations(r.dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.conn().query("SELECT version, applied_at FROM schema_version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		statuses[i].Migration = m
+		if at, ok := applied[m.Version]; ok {
+			statuses[i].AppliedAt = &at
+		}
+	}
+	return statuses, nil
+}
+
+// Migrate applies all pending migrations, each in its own transaction, and
+// returns the ones it applied.
+func (r *sqlRepository) Migrate() ([]Migration, error) {
+	statuses, err := r.MigrationStatuses()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			continue
+		}
+
+		m := status.Migration
+		err := r.inTx(func(c conn) error {
+			// Run as is, migrations can hold several statements and no placeholders
+			if _, err := c.q.Exec(m.SQL); err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+			}
+			_, err := c.exec("INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)", m.Version, m.Name, time.Now().UTC())
+			return err
+		})
+		if err != nil {
+			return applied, err
+		}
+
+		log.Printf("Applied migration %04d_%s", m.Version, m.Name)
+		applied = append(applied, m)
+	}
+
+	return applied, nil
+}
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS words (
+	id SERIAL PRIMARY KEY,
+	word TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS players (
+	id SERIAL PRIMARY KEY,
+	username TEXT NOT NULL,
+	password_hash TEXT
+);
+
+-- Usernames are case-insensitive like SQLite's COLLATE NOCASE
+CREATE UNIQUE INDEX IF NOT EXISTS players_username_lower ON players (lower(username));
+
+CREATE TABLE IF NOT EXISTS scores (
+	id SERIAL PRIMARY KEY,
+	player_id INTEGER NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+	accuracy DOUBLE PRECISION,
+	wpm DOUBLE PRECISION,
+	tp DOUBLE PRECISION,
+	duration INTEGER,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS player_keys (
+	id SERIAL PRIMARY KEY,
+	player_id INTEGER NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+	fingerprint TEXT NOT NULL UNIQUE,
+	public_key TEXT NOT NULL,
+	comment TEXT,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS score_keystrokes (
+	score_id INTEGER PRIMARY KEY REFERENCES scores(id) ON DELETE CASCADE,
+	sentence TEXT NOT NULL,
+	keystrokes TEXT NOT NULL
+);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS player_key_stats (
+	player_id INTEGER NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+	key_char TEXT NOT NULL,
+	presses INTEGER NOT NULL DEFAULT 0,
+	errors INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (player_id, key_char)
+);
+
+CREATE TABLE IF NOT EXISTS player_bigram_stats (
+	player_id INTEGER NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+	bigram TEXT NOT NULL,
+	samples INTEGER NOT NULL DEFAULT 0,
+	total_ms BIGINT NOT NULL DEFAULT 0,
+	PRIMARY KEY (player_id, bigram)
+);
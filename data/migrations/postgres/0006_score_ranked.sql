@@ -0,0 +1,2 @@
+-- Practice runs are saved but kept off the leaderboards
+ALTER TABLE scores ADD COLUMN ranked BOOLEAN NOT NULL DEFAULT TRUE;
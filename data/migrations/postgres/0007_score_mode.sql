@@ -0,0 +1,2 @@
+-- Single player format, e.g. sentence, time:30 or words:25
+ALTER TABLE scores ADD COLUMN mode TEXT NOT NULL DEFAULT 'sentence';
@@ -0,0 +1,2 @@
+-- Text typed in the run, e.g. words, quotes or code
+ALTER TABLE scores ADD COLUMN source TEXT NOT NULL DEFAULT 'words';
@@ -0,0 +1,9 @@
+-- Words belong to a language, the same word can be in several so the
+-- unique constraint moves to (language, word)
+ALTER TABLE words ADD COLUMN language TEXT NOT NULL DEFAULT 'en';
+ALTER TABLE words DROP CONSTRAINT IF EXISTS words_word_key;
+ALTER TABLE words ADD CONSTRAINT words_language_word_key UNIQUE (language, word);
+
+-- Language a player types in, and the one each run was typed in
+ALTER TABLE players ADD COLUMN language TEXT NOT NULL DEFAULT 'en';
+ALTER TABLE scores ADD COLUMN language TEXT NOT NULL DEFAULT 'en';
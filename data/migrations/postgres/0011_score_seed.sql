@@ -0,0 +1,2 @@
+-- Seed the run's text was generated from, NULL if it can't be generated again
+ALTER TABLE scores ADD COLUMN seed BIGINT;
@@ -0,0 +1,8 @@
+-- A player's one ranked daily challenge attempt per UTC day (YYYY-MM-DD). It's
+-- used up when the text is shown, score_id stays NULL if it's never finished.
+CREATE TABLE IF NOT EXISTS daily_attempts (
+	player_id INTEGER NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+	day TEXT NOT NULL,
+	score_id INTEGER REFERENCES scores(id) ON DELETE SET NULL,
+	PRIMARY KEY (player_id, day)
+);
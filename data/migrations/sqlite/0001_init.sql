@@ -0,0 +1,22 @@
+-- Tables from before migrations existed, IF NOT EXISTS so older databases adopt them as is
+CREATE TABLE IF NOT EXISTS words (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	word TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS players (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	username TEXT UNIQUE COLLATE NOCASE NOT NULL,
+	password_hash TEXT
+);
+
+CREATE TABLE IF NOT EXISTS scores (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	player_id INTEGER NOT NULL,
+	accuracy REAL,
+	wpm REAL,
+	tp REAL,
+	duration INTEGER,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY(player_id) REFERENCES players(id) ON DELETE CASCADE
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS player_keys (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	player_id INTEGER NOT NULL,
+	fingerprint TEXT NOT NULL UNIQUE,
+	public_key TEXT NOT NULL,
+	comment TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY(player_id) REFERENCES players(id) ON DELETE CASCADE
+);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS score_keystrokes (
+	score_id INTEGER PRIMARY KEY,
+	sentence TEXT NOT NULL,
+	keystrokes TEXT NOT NULL,
+	FOREIGN KEY(score_id) REFERENCES scores(id) ON DELETE CASCADE
+);
@@ -0,0 +1,8 @@
+ALTER TABLE scores ADD COLUMN raw_wpm REAL;
+ALTER TABLE scores ADD COLUMN corrected_errors INTEGER;
+ALTER TABLE scores ADD COLUMN uncorrected_errors INTEGER;
+ALTER TABLE scores ADD COLUMN insertions INTEGER;
+ALTER TABLE scores ADD COLUMN deletions INTEGER;
+ALTER TABLE scores ADD COLUMN substitutions INTEGER;
+ALTER TABLE scores ADD COLUMN consistency REAL;
+ALTER TABLE scores ADD COLUMN wpm_samples TEXT;
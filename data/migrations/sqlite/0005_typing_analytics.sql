@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS player_key_stats (
+	player_id INTEGER NOT NULL,
+	key_char TEXT NOT NULL,
+	presses INTEGER NOT NULL DEFAULT 0,
+	errors INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (player_id, key_char),
+	FOREIGN KEY(player_id) REFERENCES players(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS player_bigram_stats (
+	player_id INTEGER NOT NULL,
+	bigram TEXT NOT NULL,
+	samples INTEGER NOT NULL DEFAULT 0,
+	total_ms INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (player_id, bigram),
+	FOREIGN KEY(player_id) REFERENCES players(id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+-- Text options the run was generated with, e.g. easy, normal or hard
+ALTER TABLE scores ADD COLUMN difficulty TEXT NOT NULL DEFAULT 'easy';
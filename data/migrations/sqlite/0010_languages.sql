@@ -0,0 +1,16 @@
+-- Words belong to a language, the same word can be in several so the
+-- unique constraint moves to (language, word). SQLite can't change
+-- constraints in place, so the table is rebuilt.
+CREATE TABLE words_by_language (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	language TEXT NOT NULL DEFAULT 'en',
+	word TEXT NOT NULL,
+	UNIQUE (language, word)
+);
+INSERT INTO words_by_language (id, word) SELECT id, word FROM words;
+DROP TABLE words;
+ALTER TABLE words_by_language RENAME TO words;
+
+-- Language a player types in, and the one each run was typed in
+ALTER TABLE players ADD COLUMN language TEXT NOT NULL DEFAULT 'en';
+ALTER TABLE scores ADD COLUMN language TEXT NOT NULL DEFAULT 'en';
@@ -0,0 +1,10 @@
+-- A player's one ranked daily challenge attempt per UTC day (YYYY-MM-DD). It's
+-- used up when the text is shown, score_id stays NULL if it's never finished.
+CREATE TABLE IF NOT EXISTS daily_attempts (
+	player_id INTEGER NOT NULL,
+	day TEXT NOT NULL,
+	score_id INTEGER,
+	PRIMARY KEY (player_id, day),
+	FOREIGN KEY(player_id) REFERENCES players(id) ON DELETE CASCADE,
+	FOREIGN KEY(score_id) REFERENCES scores(id) ON DELETE SET NULL
+);
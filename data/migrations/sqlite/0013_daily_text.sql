@@ -0,0 +1,8 @@
+-- The text of each day's challenge, stored the first time it's generated so
+-- a word list reload later that day doesn't change it.
+CREATE TABLE IF NOT EXISTS daily_challenges (
+	day TEXT PRIMARY KEY,
+	seed INTEGER NOT NULL,
+	text TEXT NOT NULL,
+	created_at DATETIME NOT NULL
+);
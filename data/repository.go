@@ -0,0 +1,196 @@
+package data
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"ssh-battle/config"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("already exists")
+)
+
+// Repository is the storage the game runs on. The SQL implementation works
+// on SQLite and PostgreSQL, picked with the database driver setting.
+type Repository interface {
+	Players() PlayerRepository
+	Scores() ScoreRepository
+	Words() WordRepository
+	Keys() KeyRepository
+	Analytics() AnalyticsRepository
+	Daily() DailyRepository
+
+	// DB and Dialect are for read-only reporting queries that don't belong to
+	// one of the repositories above.
+	DB() *sql.DB
+	Dialect() Dialect
+
+	Migrate() ([]Migration, error)
+	MigrationStatuses() ([]MigrationStatus, error)
+	Close() error
+}
+
+// Repo is the repository opened by InitDB or OpenDB.
+var Repo Repository
+
+type PlayerRecord struct {
+	ID       int
+	Username string
+	// Empty for accounts that never finished registering
+	PasswordHash string
+	// Language code the player's words are generated in
+	Language string
+}
+
+type PlayerRepository interface {
+	Get(username string) (PlayerRecord, error)
+	IsRegistered(username string) (bool, error)
+	// Register commits an account with a password, claiming a row left
+	// without one. Returns ErrConflict if the name is already registered.
+	Register(username, passwordHash string) error
+	SetLanguage(playerID int, language string) error
+}
+
+type ScoreRecord struct {
+	ID        int
+	PlayerID  int
+	Accuracy  float64
+	WPM       float64
+	TP        float64
+	Duration  int
+	CreatedAt time.Time
+
+	RawWPM            float64
+	CorrectedErrors   int
+	UncorrectedErrors int
+	Insertions        int
+	Deletions         int
+	Substitutions     int
+	Consistency       float64
+	// Raw WPM per second, stored as a JSON array
+	WPMSamples []float64
+
+	// False for practice runs, which are kept off the leaderboards
+	Ranked bool
+	// Single player format, each has its own leaderboard
+	Mode string
+	// Text source the run was typed from, also its own leaderboard
+	Source string
+	// Capitals, punctuation, numbers and symbols in generated text
+	Difficulty string
+	// Language code of the text, each has its own leaderboard
+	Language string
+	// Generates the text again with the same options, stored as NULL when 0
+	// for text that can't be, like practice runs
+	Seed int64
+
+	// The sentence and the keystrokes typed for it, in the player package's
+	// encoding. Scores without keystrokes have no replay.
+	Sentence   string
+	Keystrokes string
+}
+
+type ReplayRecord struct {
+	ScoreID    int
+	Username   string
+	Sentence   string
+	Keystrokes string
+	Mode       string
+	Source     string
+	Difficulty string
+	Language   string
+	// 0 if the text can't be generated again
+	Seed      int64
+	CreatedAt time.Time
+}
+
+type ScoreRepository interface {
+	Add(score ScoreRecord) (int, error)
+	// Replay returns ErrNotFound if the score doesn't exist or has no keystrokes
+	Replay(scoreID int) (ReplayRecord, error)
+	// BestReplay returns the player's highest TP ranked score in mode with
+	// text from source that has keystrokes, ErrNotFound if there is none
+	BestReplay(playerID int, mode, source string) (ReplayRecord, error)
+}
+
+type WordRepository interface {
+	// Add inserts words that aren't in the language's list yet
+	Add(language string, words []string) error
+	All(language string) ([]string, error)
+	// Languages returns the codes that have words, sorted
+	Languages() ([]string, error)
+}
+
+type KeyRecord struct {
+	ID          int
+	PlayerID    int
+	Fingerprint string
+	// In authorized_keys format
+	PublicKey string
+	Comment   string
+	CreatedAt time.Time
+}
+
+type KeyRepository interface {
+	// Registered reports whether the key with fingerprint belongs to username
+	Registered(username, fingerprint string) (bool, error)
+	List(playerID int) ([]KeyRecord, error)
+	// Add returns ErrConflict if the fingerprint is registered to any account
+	Add(key KeyRecord) (int, error)
+	// Delete returns ErrNotFound if the player has no key with that fingerprint
+	Delete(playerID int, fingerprint string) error
+}
+
+type KeyStatRecord struct {
+	Key     string
+	Presses int
+	Errors  int
+}
+
+type BigramStatRecord struct {
+	Bigram  string
+	Samples int
+	TotalMS int64
+}
+
+// AnalyticsRepository keeps running per player totals of how each key and
+// letter pair was typed.
+type AnalyticsRepository interface {
+	// Add adds one run's counts to the player's totals
+	Add(playerID int, keys []KeyStatRecord, bigrams []BigramStatRecord) error
+	Keys(playerID int) ([]KeyStatRecord, error)
+	Bigrams(playerID int) ([]BigramStatRecord, error)
+}
+
+// DailyRepository tracks the one ranked daily challenge attempt each player
+// gets per day. Days are UTC dates written as YYYY-MM-DD.
+type DailyRepository interface {
+	// Used reports whether the player already started their ranked attempt
+	Used(playerID int, day string) (bool, error)
+	// Start uses up the player's ranked attempt for day, false if it was
+	// already used
+	Start(playerID int, day string) (bool, error)
+	// Finish links the day's ranked attempt to the score it was saved as
+	Finish(playerID int, day string, scoreID int) error
+	// Text returns the day's challenge text, ErrNotFound until it's saved
+	Text(day string) (string, error)
+	// SaveText stores the day's text and the seed it was generated from.
+	// If another session saved one first, that one is kept and returned.
+	SaveText(day string, seed int64, text string) (string, error)
+}
+
+// Open connects to the database picked by cfg.Driver without touching its schema.
+func Open(cfg config.Database) (Repository, error) {
+	switch cfg.Driver {
+	case "", "sqlite":
+		return openSQLite(cfg.Path)
+	case "postgres":
+		return openPostgres(cfg.DSN)
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,496 @@
+package data
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ssh-battle/config"
+)
+
+// pgDSNEnv points the tests at a PostgreSQL database as well as SQLite. Every
+// test gets a schema of its own in it, dropped again when the test ends.
+const pgDSNEnv = "SSH_BATTLE_TEST_PG_DSN"
+
+// eachBackend runs fn against a freshly migrated SQLite database, and against
+// PostgreSQL when SSH_BATTLE_TEST_PG_DSN is set.
+func eachBackend(t *testing.T, fn func(t *testing.T, r Repository)) {
+	t.Helper()
+	t.Run("sqlite", func(t *testing.T) {
+		fn(t, openTestSQLite(t))
+	})
+	t.Run("postgres", func(t *testing.T) {
+		dsn := os.Getenv(pgDSNEnv)
+		if dsn == "" {
+			t.Skip(pgDSNEnv + " not set")
+		}
+		fn(t, openTestPostgres(t, dsn))
+	})
+}
+
+// openTestSQLite opens a migrated SQLite database in a temp dir that's removed
+// when t ends.
+func openTestSQLite(t testing.TB) Repository {
+	t.Helper()
+	return openTest(t, config.Database{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "game.db")})
+}
+
+func openTestPostgres(t *testing.T, dsn string) Repository {
+	t.Helper()
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		admin, err := sql.Open("postgres", dsn)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer admin.Close()
+		if _, err := admin.Exec("DROP SCHEMA " + schema + " CASCADE"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	// lib/pq passes unknown settings on to the server
+	if strings.Contains(dsn, "://") {
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		dsn += sep + "search_path=" + schema
+	} else {
+		dsn += " search_path=" + schema
+	}
+	return openTest(t, config.Database{Driver: "postgres", DSN: dsn})
+}
+
+func openTest(t testing.TB, cfg config.Database) Repository {
+	t.Helper()
+	repo, err := Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	if _, err := repo.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+// addPlayer registers username and returns its id.
+func addPlayer(t *testing.T, r Repository, username string) int {
+	t.Helper()
+	if err := r.Players().Register(username, "hash"); err != nil {
+		t.Fatalf("Register(%q): %v", username, err)
+	}
+	p, err := r.Players().Get(username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.ID
+}
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect Dialect
+		query   string
+		want    string
+	}{
+		{"sqlite keeps ?", SQLite, "SELECT * FROM t WHERE a = ? AND b = ?", "SELECT * FROM t WHERE a = ? AND b = ?"},
+		{"postgres numbers", Postgres, "SELECT * FROM t WHERE a = ? AND b = ?", "SELECT * FROM t WHERE a = $1 AND b = $2"},
+		{"postgres no placeholders", Postgres, "SELECT 1", "SELECT 1"},
+		{"postgres skips strings", Postgres, "SELECT '?' FROM t WHERE a = ? AND b = 'x?y'", "SELECT '?' FROM t WHERE a = $1 AND b = 'x?y'"},
+		{"postgres escaped quote", Postgres, "SELECT 'it''s?' WHERE a = ?", "SELECT 'it''s?' WHERE a = $1"},
+		{"postgres multibyte", Postgres, "SELECT 'é' WHERE a = ? OR b = ?", "SELECT 'é' WHERE a = $1 OR b = $2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.dialect.Rebind(tt.query); got != tt.want {
+				t.Errorf("Rebind(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	eachBackend(t, func(t *testing.T, r Repository) {
+		statuses, err := r.MigrationStatuses()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(statuses) == 0 {
+			t.Fatal("no migrations found")
+		}
+		for i, s := range statuses {
+			if s.AppliedAt == nil {
+				t.Errorf("migration %04d_%s not applied", s.Version, s.Name)
+			}
+			if i > 0 && s.Version <= statuses[i-1].Version {
+				t.Errorf("migration %d listed after %d", s.Version, statuses[i-1].Version)
+			}
+		}
+
+		var versions int
+		if err := r.DB().QueryRow("SELECT COUNT(*) FROM schema_version").Scan(&versions); err != nil {
+			t.Fatal(err)
+		}
+		if versions != len(statuses) {
+			t.Errorf("schema_version has %d rows, want %d", versions, len(statuses))
+		}
+
+		applied, err := r.Migrate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(applied) != 0 {
+			t.Errorf("second Migrate applied %d migrations, want 0", len(applied))
+		}
+	})
+}
+
+func TestMigrationsMatchAcrossDialects(t *testing.T) {
+	sqlite, err := loadMigrations(SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	postgres, err := loadMigrations(Postgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sqlite) != len(postgres) {
+		t.Fatalf("%d sqlite migrations, %d postgres", len(sqlite), len(postgres))
+	}
+	for i := range sqlite {
+		if sqlite[i].Version != postgres[i].Version || sqlite[i].Name != postgres[i].Name {
+			t.Errorf("sqlite %04d_%s doesn't match postgres %04d_%s",
+				sqlite[i].Version, sqlite[i].Name, postgres[i].Version, postgres[i].Name)
+		}
+	}
+}
+
+func TestPlayers(t *testing.T) {
+	eachBackend(t, func(t *testing.T, r Repository) {
+		players := r.Players()
+		id := addPlayer(t, r, "Alice")
+
+		tests := []struct {
+			name     string
+			register string
+			want     error
+		}{
+			{"same name", "Alice", ErrConflict},
+			{"other case", "aLICE", ErrConflict},
+			{"new name", "bob", nil},
+		}
+		for _, tt := range tests {
+			if err := players.Register(tt.register, "other"); !errors.Is(err, tt.want) {
+				t.Errorf("%s: Register(%q) = %v, want %v", tt.name, tt.register, err, tt.want)
+			}
+		}
+
+		p, err := players.Get("ALICE")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.ID != id || p.Username != "Alice" || p.PasswordHash != "hash" {
+			t.Errorf("Get(ALICE) = %+v, want Alice's account", p)
+		}
+		if _, err := players.Get("nobody"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(nobody) = %v, want ErrNotFound", err)
+		}
+
+		// The unique index on the lowercased name holds outside Register too
+		if _, err := r.DB().Exec(r.Dialect().Rebind("INSERT INTO players (username) VALUES (?)"), "ALICE"); err == nil {
+			t.Error("inserted ALICE next to Alice")
+		}
+	})
+}
+
+func TestRegisterClaimsUnfinishedAccount(t *testing.T) {
+	eachBackend(t, func(t *testing.T, r Repository) {
+		if _, err := r.DB().Exec(r.Dialect().Rebind("INSERT INTO players (username) VALUES (?)"), "carol"); err != nil {
+			t.Fatal(err)
+		}
+		if registered, err := r.Players().IsRegistered("carol"); err != nil || registered {
+			t.Fatalf("IsRegistered(carol) = %v, %v before registering", registered, err)
+		}
+
+		if err := r.Players().Register("Carol", "hash"); err != nil {
+			t.Fatal(err)
+		}
+		if registered, err := r.Players().IsRegistered("CAROL"); err != nil || !registered {
+			t.Errorf("IsRegistered(CAROL) = %v, %v after registering", registered, err)
+		}
+
+		var rows int
+		if err := r.DB().QueryRow("SELECT COUNT(*) FROM players").Scan(&rows); err != nil {
+			t.Fatal(err)
+		}
+		if rows != 1 {
+			t.Errorf("%d player rows, want the unfinished one reused", rows)
+		}
+	})
+}
+
+func TestKeys(t *testing.T) {
+	eachBackend(t, func(t *testing.T, r Repository) {
+		alice := addPlayer(t, r, "alice")
+		bob := addPlayer(t, r, "bob")
+		keys := r.Keys()
+
+		if _, err := keys.Add(KeyRecord{PlayerID: alice, Fingerprint: "SHA256:a", PublicKey: "ssh-ed25519 AAAA"}); err != nil {
+			t.Fatal(err)
+		}
+		// A fingerprint belongs to one account only
+		for _, id := range []int{alice, bob} {
+			if _, err := keys.Add(KeyRecord{PlayerID: id, Fingerprint: "SHA256:a", PublicKey: "ssh-ed25519 AAAA"}); !errors.Is(err, ErrConflict) {
+				t.Errorf("adding the key again for player %d = %v, want ErrConflict", id, err)
+			}
+		}
+
+		tests := []struct {
+			username    string
+			fingerprint string
+			want        bool
+		}{
+			{"alice", "SHA256:a", true},
+			{"ALICE", "SHA256:a", true},
+			{"bob", "SHA256:a", false},
+			{"alice", "SHA256:b", false},
+		}
+		for _, tt := range tests {
+			got, err := keys.Registered(tt.username, tt.fingerprint)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Registered(%q, %q) = %v, want %v", tt.username, tt.fingerprint, got, tt.want)
+			}
+		}
+
+		if err := keys.Delete(bob, "SHA256:a"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("deleting alice's key as bob = %v, want ErrNotFound", err)
+		}
+		if err := keys.Delete(alice, "SHA256:a"); err != nil {
+			t.Fatal(err)
+		}
+		if list, err := keys.List(alice); err != nil || len(list) != 0 {
+			t.Errorf("List after Delete = %v, %v", list, err)
+		}
+	})
+}
+
+func TestScores(t *testing.T) {
+	eachBackend(t, func(t *testing.T, r Repository) {
+		alice := addPlayer(t, r, "alice")
+		scores := r.Scores()
+
+		created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+		withKeys, err := scores.Add(ScoreRecord{
+			PlayerID: alice, TP: 10, CreatedAt: created, Ranked: true,
+			Mode: "25w", Source: "quotes", Difficulty: "hard", Language: "nl", Seed: 42,
+			WPMSamples: []float64{50, 60},
+			Sentence:   "hallo wereld", Keystrokes: "encoded",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		plain, err := scores.Add(ScoreRecord{PlayerID: alice, TP: 20, CreatedAt: created, Mode: "sentence", Source: "words", Language: "en"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rec, err := scores.Replay(withKeys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec.Username != "alice" || rec.Sentence != "hallo wereld" || rec.Keystrokes != "encoded" ||
+			rec.Mode != "25w" || rec.Source != "quotes" || rec.Difficulty != "hard" || rec.Language != "nl" || rec.Seed != 42 || !rec.CreatedAt.Equal(created) {
+			t.Errorf("Replay = %+v", rec)
+		}
+		if _, err := scores.Replay(plain); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Replay of a score without keystrokes = %v, want ErrNotFound", err)
+		}
+
+		// The higher TP run has no keystrokes, so it can't be the best replay
+		best, err := scores.BestReplay(alice, "25w", "quotes")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if best.ScoreID != withKeys {
+			t.Errorf("BestReplay = score %d, want %d", best.ScoreID, withKeys)
+		}
+		// Practice runs and runs in other modes or from other sources don't count
+		for _, s := range []ScoreRecord{
+			{PlayerID: alice, TP: 30, CreatedAt: created, Mode: "25w", Source: "quotes", Sentence: "practice", Keystrokes: "encoded"},
+			{PlayerID: alice, TP: 40, CreatedAt: created, Ranked: true, Mode: "50w", Source: "quotes", Sentence: "longer", Keystrokes: "encoded"},
+			{PlayerID: alice, TP: 50, CreatedAt: created, Ranked: true, Mode: "25w", Source: "words", Sentence: "words", Keystrokes: "encoded"},
+		} {
+			if _, err := scores.Add(s); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if best, err := scores.BestReplay(alice, "25w", "quotes"); err != nil || best.ScoreID != withKeys {
+			t.Errorf("BestReplay = score %d, %v, want %d", best.ScoreID, err, withKeys)
+		}
+		if _, err := scores.BestReplay(alice, "sentence", "words"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("BestReplay without a ranked run in the mode = %v, want ErrNotFound", err)
+		}
+
+		var seed sql.NullInt64
+		if err := r.DB().QueryRow(r.Dialect().Rebind("SELECT seed FROM scores WHERE id = ?"), plain).Scan(&seed); err != nil {
+			t.Fatal(err)
+		}
+		if seed.Valid {
+			t.Errorf("seed 0 stored as %d, want NULL", seed.Int64)
+		}
+	})
+}
+
+func TestWords(t *testing.T) {
+	eachBackend(t, func(t *testing.T, r Repository) {
+		words := r.Words()
+		if err := words.Add("en", []string{"the", "quick", "fox"}); err != nil {
+			t.Fatal(err)
+		}
+		// Words already in a list are skipped, other languages have their own
+		if err := words.Add("en", []string{"quick", "brown"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := words.Add("nl", []string{"de", "the"}); err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			language string
+			want     []string
+		}{
+			{"en", []string{"the", "quick", "fox", "brown"}},
+			{"nl", []string{"de", "the"}},
+			{"de", nil},
+		}
+		for _, tt := range tests {
+			got, err := words.All(tt.language)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("All(%q) = %q, want %q", tt.language, got, tt.want)
+			}
+		}
+
+		languages, err := words.Languages()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(languages, ",") != "en,nl" {
+			t.Errorf("Languages() = %q, want [en nl]", languages)
+		}
+	})
+}
+
+func TestAnalyticsAddsUp(t *testing.T) {
+	eachBackend(t, func(t *testing.T, r Repository) {
+		alice := addPlayer(t, r, "alice")
+		analytics := r.Analytics()
+
+		for range 2 {
+			err := analytics.Add(alice,
+				[]KeyStatRecord{{Key: "a", Presses: 3, Errors: 1}},
+				[]BigramStatRecord{{Bigram: "ab", Samples: 2, TotalMS: 300}})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		keys, err := analytics.Keys(alice)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 1 || keys[0] != (KeyStatRecord{Key: "a", Presses: 6, Errors: 2}) {
+			t.Errorf("Keys = %+v, want a pressed 6 times with 2 errors", keys)
+		}
+		bigrams, err := analytics.Bigrams(alice)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bigrams) != 1 || bigrams[0] != (BigramStatRecord{Bigram: "ab", Samples: 4, TotalMS: 600}) {
+			t.Errorf("Bigrams = %+v, want ab with 4 samples over 600ms", bigrams)
+		}
+	})
+}
+
+func TestDailyOneRankedAttempt(t *testing.T) {
+	eachBackend(t, func(t *testing.T, r Repository) {
+		alice := addPlayer(t, r, "alice")
+		daily := r.Daily()
+
+		steps := []struct {
+			day  string
+			want bool
+		}{
+			{"2026-03-01", true},
+			{"2026-03-01", false},
+			{"2026-03-02", true},
+		}
+		for _, s := range steps {
+			got, err := daily.Start(alice, s.day)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != s.want {
+				t.Errorf("Start(%s) = %v, want %v", s.day, got, s.want)
+			}
+			if used, err := daily.Used(alice, s.day); err != nil || !used {
+				t.Errorf("Used(%s) = %v, %v after Start", s.day, used, err)
+			}
+		}
+		if used, err := daily.Used(alice, "2026-03-03"); err != nil || used {
+			t.Errorf("Used on an unplayed day = %v, %v", used, err)
+		}
+	})
+}
+
+func TestDailyText(t *testing.T) {
+	eachBackend(t, func(t *testing.T, r Repository) {
+		daily := r.Daily()
+		if _, err := daily.Text("2026-03-01"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Text before saving = %v, want ErrNotFound", err)
+		}
+
+		// Whoever saves the day's text first decides it for everyone
+		steps := []struct {
+			day, text, want string
+		}{
+			{"2026-03-01", "first words", "first words"},
+			{"2026-03-01", "reloaded words", "first words"},
+			{"2026-03-02", "next day", "next day"},
+		}
+		for _, s := range steps {
+			got, err := daily.SaveText(s.day, 42, s.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != s.want {
+				t.Errorf("SaveText(%s, %q) = %q, want %q", s.day, s.text, got, s.want)
+			}
+		}
+		if got, err := daily.Text("2026-03-01"); err != nil || got != "first words" {
+			t.Errorf("Text = %q, %v, want the first text", got, err)
+		}
+	})
+}
@@ -0,0 +1,402 @@
+package data
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// querier is satisfied by both *sql.DB and *sql.Tx.
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// conn runs ? placeholder queries against a database or transaction.
+type conn struct {
+	q       querier
+	dialect Dialect
+}
+
+func (c conn) exec(query string, args ...any) (sql.Result, error) {
+	return c.q.Exec(c.dialect.Rebind(query), args...)
+}
+
+func (c conn) query(query string, args ...any) (*sql.Rows, error) {
+	return c.q.Query(c.dialect.Rebind(query), args...)
+}
+
+func (c conn) queryRow(query string, args ...any) *sql.Row {
+	return c.q.QueryRow(c.dialect.Rebind(query), args...)
+}
+
+// insert runs an INSERT ... RETURNING id and returns the id.
+func (c conn) insert(query string, args ...any) (int, error) {
+	var id int
+	err := c.queryRow(query+" RETURNING id", args...).Scan(&id)
+	return id, err
+}
+
+type sqlRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func openSQL(dialect Dialect, dsn string) (*sqlRepository, error) {
+	db, err := sql.Open(dialect.driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqlRepository{db: db, dialect: dialect}, nil
+}
+
+func (r *sqlRepository) conn() conn {
+	return conn{q: r.db, dialect: r.dialect}
+}
+
+func (r *sqlRepository) inTx(fn func(c conn) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(conn{q: tx, dialect: r.dialect}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *sqlRepository) Players() PlayerRepository      { return sqlPlayers{r} }
+func (r *sqlRepository) Scores() ScoreRepository        { return sqlScores{r} }
+func (r *sqlRepository) Words() WordRepository          { return sqlWords{r} }
+func (r *sqlRepository) Keys() KeyRepository            { return sqlKeys{r} }
+func (r *sqlRepository) Analytics() AnalyticsRepository { return sqlAnalytics{r} }
+func (r *sqlRepository) Daily() DailyRepository         { return sqlDaily{r} }
+func (r *sqlRepository) DB() *sql.DB                    { return r.db }
+func (r *sqlRepository) Dialect() Dialect               { return r.dialect }
+func (r *sqlRepository) Close() error                   { return r.db.Close() }
+
+type sqlPlayers struct{ *sqlRepository }
+
+func (r sqlPlayers) Get(username string) (PlayerRecord, error) {
+	var p PlayerRecord
+	var hash sql.NullString
+	err := r.conn().queryRow("SELECT id, username, password_hash, language FROM players WHERE lower(username) = lower(?)", username).
+		Scan(&p.ID, &p.Username, &hash, &p.Language)
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, ErrNotFound
+	}
+	p.PasswordHash = hash.String
+	return p, err
+}
+
+func (r sqlPlayers) IsRegistered(username string) (bool, error) {
+	var registered bool
+	err := r.conn().queryRow("SELECT EXISTS(SELECT 1 FROM players WHERE lower(username) = lower(?) AND password_hash IS NOT NULL)", username).
+		Scan(&registered)
+	return registered, err
+}
+
+func (r sqlPlayers) Register(username, passwordHash string) error {
+	return r.inTx(func(c conn) error {
+		res, err := c.exec("UPDATE players SET password_hash = ? WHERE lower(username) = lower(?) AND password_hash IS NULL", passwordHash, username)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n > 0 {
+			return err
+		}
+
+		// The unique username decides who wins if two connections race here
+		res, err = c.exec("INSERT INTO players (username, password_hash) VALUES (?, ?) ON CONFLICT DO NOTHING", username, passwordHash)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrConflict
+		}
+		return nil
+	})
+}
+
+func (r sqlPlayers) SetLanguage(playerID int, language string) error {
+	_, err := r.conn().exec("UPDATE players SET language = ? WHERE id = ?", language, playerID)
+	return err
+}
+
+type sqlScores struct{ *sqlRepository }
+
+func (r sqlScores) Add(s ScoreRecord) (int, error) {
+	var id int
+	err := r.inTx(func(c conn) error {
+		samples, err := json.Marshal(s.WPMSamples)
+		if err != nil {
+			return err
+		}
+
+		id, err = c.insert(`
+			INSERT INTO scores (
+				player_id, accuracy, wpm, tp, duration, created_at,
+				raw_wpm, corrected_errors, uncorrected_errors,
+				insertions, deletions, substitutions, consistency, wpm_samples, ranked, mode, source, difficulty, language, seed
+			)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			s.PlayerID, s.Accuracy, s.WPM, s.TP, s.Duration, s.CreatedAt,
+			s.RawWPM, s.CorrectedErrors, s.UncorrectedErrors,
+			s.Insertions, s.Deletions, s.Substitutions, s.Consistency, string(samples), s.Ranked, s.Mode, s.Source, s.Difficulty, s.Language, sql.NullInt64{Int64: s.Seed, Valid: s.Seed != 0})
+		if err != nil || s.Keystrokes == "" {
+			return err
+		}
+
+		_, err = c.exec("INSERT INTO score_keystrokes (score_id, sentence, keystrokes) VALUES (?, ?, ?)", id, s.Sentence, s.Keystrokes)
+		return err
+	})
+	return id, err
+}
+
+func (r sqlScores) Replay(scoreID int) (ReplayRecord, error) {
+	return r.replay("WHERE k.score_id = ?", scoreID)
+}
+
+func (r sqlScores) BestReplay(playerID int, mode, source string) (ReplayRecord, error) {
+	return r.replay("WHERE s.player_id = ? AND s.ranked = ? AND s.mode = ? AND s.source = ? ORDER BY s.tp DESC, s.id LIMIT 1", playerID, true, mode, source)
+}
+
+func (r sqlScores) replay(where string, args ...any) (ReplayRecord, error) {
+	var rec ReplayRecord
+	err := r.conn().queryRow(`
+		SELECT s.id, p.username, k.sentence, k.keystrokes, s.mode, s.source, s.difficulty, s.language, COALESCE(s.seed, 0), s.created_at
+		FROM score_keystrokes k
+		JOIN scores s ON s.id = k.score_id
+		JOIN players p ON p.id = s.player_id
+		`+where, args...).
+		Scan(&rec.ScoreID, &rec.Username, &rec.Sentence, &rec.Keystrokes, &rec.Mode, &rec.Source, &rec.Difficulty, &rec.Language, &rec.Seed, &rec.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return rec, ErrNotFound
+	}
+	return rec, err
+}
+
+type sqlWords struct{ *sqlRepository }
+
+func (r sqlWords) Add(language string, words []string) error {
+	return r.inTx(func(c conn) error {
+		for _, word := range words {
+			if _, err := c.exec("INSERT INTO words (language, word) VALUES (?, ?) ON CONFLICT DO NOTHING", language, word); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r sqlWords) All(language string) ([]string, error) {
+	// Generated text is picked by position, so the order has to stay the
+	// same for seeds to give the same words
+	return r.strings("SELECT word FROM words WHERE language = ? ORDER BY id", language)
+}
+
+func (r sqlWords) Languages() ([]string, error) {
+	return r.strings("SELECT DISTINCT language FROM words ORDER BY language")
+}
+
+// strings runs a query for a single text column.
+func (r sqlWords) strings(query string, args ...any) ([]string, error) {
+	rows, err := r.conn().query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+type sqlKeys struct{ *sqlRepository }
+
+func (r sqlKeys) Registered(username, fingerprint string) (bool, error) {
+	var exists bool
+	err := r.conn().queryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM player_keys k
+			JOIN players p ON p.id = k.player_id
+			WHERE lower(p.username) = lower(?) AND k.fingerprint = ?
+		)`, username, fingerprint).Scan(&exists)
+	return exists, err
+}
+
+func (r sqlKeys) List(playerID int) ([]KeyRecord, error) {
+	rows, err := r.conn().query("SELECT id, player_id, fingerprint, public_key, COALESCE(comment, ''), created_at FROM player_keys WHERE player_id = ? ORDER BY id", playerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []KeyRecord
+	for rows.Next() {
+		var k KeyRecord
+		if err := rows.Scan(&k.ID, &k.PlayerID, &k.Fingerprint, &k.PublicKey, &k.Comment, &k.CreatedAt); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (r sqlKeys) Add(k KeyRecord) (int, error) {
+	if k.CreatedAt.IsZero() {
+		k.CreatedAt = time.Now().UTC()
+	}
+
+	id, err := r.conn().insert(`
+		INSERT INTO player_keys (player_id, fingerprint, public_key, comment, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT DO NOTHING`,
+		k.PlayerID, k.Fingerprint, k.PublicKey, k.Comment, k.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Nothing was returned, so the fingerprint is taken
+		return 0, ErrConflict
+	}
+	return id, err
+}
+
+func (r sqlKeys) Delete(playerID int, fingerprint string) error {
+	res, err := r.conn().exec("DELETE FROM player_keys WHERE player_id = ? AND fingerprint = ?", playerID, fingerprint)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type sqlAnalytics struct{ *sqlRepository }
+
+func (r sqlAnalytics) Add(playerID int, keys []KeyStatRecord, bigrams []BigramStatRecord) error {
+	return r.inTx(func(c conn) error {
+		for _, k := range keys {
+			_, err := c.exec(`
+				INSERT INTO player_key_stats (player_id, key_char, presses, errors)
+				VALUES (?, ?, ?, ?)
+				ON CONFLICT (player_id, key_char) DO UPDATE SET
+					presses = player_key_stats.presses + excluded.presses,
+					errors = player_key_stats.errors + excluded.errors`,
+				playerID, k.Key, k.Presses, k.Errors)
+			if err != nil {
+				return err
+			}
+		}
+		for _, b := range bigrams {
+			_, err := c.exec(`
+				INSERT INTO player_bigram_stats (player_id, bigram, samples, total_ms)
+				VALUES (?, ?, ?, ?)
+				ON CONFLICT (player_id, bigram) DO UPDATE SET
+					samples = player_bigram_stats.samples + excluded.samples,
+					total_ms = player_bigram_stats.total_ms + excluded.total_ms`,
+				playerID, b.Bigram, b.Samples, b.TotalMS)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r sqlAnalytics) Keys(playerID int) ([]KeyStatRecord, error) {
+	rows, err := r.conn().query("SELECT key_char, presses, errors FROM player_key_stats WHERE player_id = ?", playerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []KeyStatRecord
+	for rows.Next() {
+		var k KeyStatRecord
+		if err := rows.Scan(&k.Key, &k.Presses, &k.Errors); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (r sqlAnalytics) Bigrams(playerID int) ([]BigramStatRecord, error) {
+	rows, err := r.conn().query("SELECT bigram, samples, total_ms FROM player_bigram_stats WHERE player_id = ?", playerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bigrams []BigramStatRecord
+	for rows.Next() {
+		var b BigramStatRecord
+		if err := rows.Scan(&b.Bigram, &b.Samples, &b.TotalMS); err != nil {
+			return nil, err
+		}
+		bigrams = append(bigrams, b)
+	}
+	return bigrams, rows.Err()
+}
+
+type sqlDaily struct{ *sqlRepository }
+
+func (r sqlDaily) Used(playerID int, day string) (bool, error) {
+	var used bool
+	err := r.conn().queryRow("SELECT EXISTS(SELECT 1 FROM daily_attempts WHERE player_id = ? AND day = ?)", playerID, day).Scan(&used)
+	return used, err
+}
+
+func (r sqlDaily) Start(playerID int, day string) (bool, error) {
+	// The primary key decides who wins if two sessions start at once
+	res, err := r.conn().exec("INSERT INTO daily_attempts (player_id, day) VALUES (?, ?) ON CONFLICT DO NOTHING", playerID, day)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r sqlDaily) Finish(playerID int, day string, scoreID int) error {
+	_, err := r.conn().exec("UPDATE daily_attempts SET score_id = ? WHERE player_id = ? AND day = ?", scoreID, playerID, day)
+	return err
+}
+
+func (r sqlDaily) Text(day string) (string, error) {
+	var text string
+	err := r.conn().queryRow("SELECT text FROM daily_challenges WHERE day = ?", day).Scan(&text)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	return text, err
+}
+
+func (r sqlDaily) SaveText(day string, seed int64, text string) (string, error) {
+	// The primary key decides whose text everyone gets if two generate it at once
+	_, err := r.conn().exec("INSERT INTO daily_challenges (day, seed, text, created_at) VALUES (?, ?, ?, ?) ON CONFLICT DO NOTHING", day, seed, text, time.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+	return r.Text(day)
+}
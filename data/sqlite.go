@@ -0,0 +1,18 @@
+package data
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func openSQLite(path string) (*sqlRepository, error) {
+	// Make sure the directory exists
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	return openSQL(SQLite, path)
+}
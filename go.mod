@@ -3,11 +3,14 @@ module ssh-battle
 go 1.24.4
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/creack/pty v1.1.24
 	github.com/gliderlabs/ssh v0.3.8
+	github.com/lib/pq v1.10.9
 	github.com/mattn/go-sqlite3 v1.14.28
 	golang.org/x/crypto v0.31.0
 	golang.org/x/term v0.27.0
+	golang.org/x/text v0.21.0
 )
 
 require (
@@ -1,20 +1,112 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"ssh-battle/config"
 	"ssh-battle/data"
+	"ssh-battle/player"
+	"ssh-battle/scenes"
 	"ssh-battle/server"
+	"ssh-battle/util"
+	"syscall"
 )
 
-
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrate(os.Args[2:])
+		return
+	}
+
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Init DB and check for errors
-	data.InitDB()
+	data.InitDB(cfg.Database)
 	defer data.CloseDB()
 
-	// Seed the words table (insert if not exists)
-	data.SeedWords("data/words.txt")
+	// Seed the words table (insert if not exists) and load it into memory.
+	// Without words only quotes and code can be played until a reload works.
+	if _, err := util.ReloadCorpus(cfg.Game); err != nil {
+		log.Println("Failed to load words:", err)
+	}
+
+	player.AllowGuests = cfg.Server.AllowGuests
+	player.Admins = cfg.Server.Admins
+	scenes.Configure(cfg.Game)
+
+	// SIGHUP reloads the word lists without a restart, like :reload
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if _, err := util.ReloadCorpus(cfg.Game); err != nil {
+				log.Println("Failed to reload words, keeping the old ones:", err)
+			}
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// A second signal kills the process without waiting
+		<-ctx.Done()
+		stop()
+	}()
 
 	log.Println("Starting server...")
-	server.StartServer()
+	if err := server.StartServer(ctx, cfg.Server); err != nil {
+		log.Println("Server error:", err)
+	}
+	log.Println("Server stopped")
+}
+
+// migrate implements "ssh-battle migrate [status|up] [flags]" so the schema
+// can be checked or upgraded without starting the server.
+func migrate(args []string) {
+	action := "status"
+	if len(args) > 0 && (args[0] == "status" || args[0] == "up") {
+		action = args[0]
+		args = args[1:]
+	}
+
+	cfg, err := config.Load(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := data.OpenDB(cfg.Database); err != nil {
+		log.Fatal(err)
+	}
+	defer data.CloseDB()
+
+	if action == "up" {
+		applied, err := data.Repo.Migrate()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Applied %d migration(s)\n", len(applied))
+	}
+
+	statuses, err := data.Repo.MigrationStatuses()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pending := 0
+	for _, m := range statuses {
+		applied := "pending"
+		if m.AppliedAt != nil {
+			applied = "applied " + m.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		} else {
+			pending++
+		}
+		fmt.Printf("%04d  %-24s %s\n", m.Version, m.Name, applied)
+	}
+	fmt.Printf("%d pending\n", pending)
 }
@@ -0,0 +1,151 @@
+package player
+
+// EditOp is one step of turning the reference into what was typed.
+type EditOp int
+
+const (
+	OpMatch EditOp = iota
+	// A reference item typed as something else
+	OpSubstitute
+	// An extra item that isn't in the reference
+	OpInsert
+	// A reference item that was skipped
+	OpDelete
+)
+
+// EditCounts tallies the non-matching steps of an alignment.
+type EditCounts struct {
+	Insertions    int
+	Deletions     int
+	Substitutions int
+}
+
+func (c EditCounts) Total() int {
+	return c.Insertions + c.Deletions + c.Substitutions
+}
+
+func countEdits(ops []EditOp) EditCounts {
+	var c EditCounts
+	for _, op := range ops {
+		switch op {
+		case OpInsert:
+			c.Insertions++
+		case OpDelete:
+			c.Deletions++
+		case OpSubstitute:
+			c.Substitutions++
+		}
+	}
+	return c
+}
+
+// editCounts counts the edits of align's script without keeping the whole
+// table, two rows of it are enough. Scoring only needs the counts, and a long
+// timed run would otherwise need a table of millions of cells.
+func editCounts[T comparable](ref, pred []T, openEnd bool) EditCounts {
+	// Each cell also carries the counts of the path align would trace back
+	// from it, picking its steps in the same order
+	prev, cur := make([]int, len(ref)+1), make([]int, len(ref)+1)
+	prevCounts, curCounts := make([]EditCounts, len(ref)+1), make([]EditCounts, len(ref)+1)
+	for j := range prev {
+		prev[j] = j
+		prevCounts[j].Deletions = j
+	}
+	for i := 1; i <= len(pred); i++ {
+		cur[0] = i
+		curCounts[0] = EditCounts{Insertions: i}
+		for j := 1; j <= len(ref); j++ {
+			sub := prev[j-1]
+			if pred[i-1] != ref[j-1] {
+				sub++
+			}
+			cur[j] = min(sub, prev[j]+1, cur[j-1]+1)
+
+			switch {
+			case pred[i-1] == ref[j-1] && cur[j] == prev[j-1]:
+				curCounts[j] = prevCounts[j-1]
+			case cur[j] == prev[j-1]+1:
+				curCounts[j] = prevCounts[j-1]
+				curCounts[j].Substitutions++
+			case cur[j] == cur[j-1]+1:
+				curCounts[j] = curCounts[j-1]
+				curCounts[j].Deletions++
+			default:
+				curCounts[j] = prevCounts[j]
+				curCounts[j].Insertions++
+			}
+		}
+		prev, cur = cur, prev
+		prevCounts, curCounts = curCounts, prevCounts
+	}
+
+	end := len(ref)
+	if openEnd {
+		for j := range prev {
+			if prev[j] <= prev[end] {
+				end = j
+			}
+		}
+	}
+	return prevCounts[end]
+}
+
+// align returns the cheapest (Levenshtein) edit script turning ref into pred.
+// With openEnd the reference after the last typed item is free and left out
+// of the script, so an attempt submitted early isn't charged for the part it
+// never reached.
+func align[T comparable](ref, pred []T, openEnd bool) []EditOp {
+	// dist[i][j] is the cost of turning ref[:j] into pred[:i]
+	dist := make([][]int, len(pred)+1)
+	for i := range dist {
+		dist[i] = make([]int, len(ref)+1)
+		dist[i][0] = i
+	}
+	for j := range dist[0] {
+		dist[0][j] = j
+	}
+	for i := 1; i <= len(pred); i++ {
+		for j := 1; j <= len(ref); j++ {
+			sub := dist[i-1][j-1]
+			if pred[i-1] != ref[j-1] {
+				sub++
+			}
+			dist[i][j] = min(sub, dist[i-1][j]+1, dist[i][j-1]+1)
+		}
+	}
+
+	// Where the alignment ends in ref, the furthest of the cheapest ends
+	end := len(ref)
+	if openEnd {
+		last := dist[len(pred)]
+		for j := range last {
+			if last[j] <= last[end] {
+				end = j
+			}
+		}
+	}
+
+	var ops []EditOp
+	i, j := len(pred), end
+	for i > 0 || j > 0 {
+		switch {
+		case i > 0 && j > 0 && pred[i-1] == ref[j-1] && dist[i][j] == dist[i-1][j-1]:
+			ops = append(ops, OpMatch)
+			i, j = i-1, j-1
+		case i > 0 && j > 0 && dist[i][j] == dist[i-1][j-1]+1:
+			ops = append(ops, OpSubstitute)
+			i, j = i-1, j-1
+		case j > 0 && dist[i][j] == dist[i][j-1]+1:
+			ops = append(ops, OpDelete)
+			j--
+		default:
+			ops = append(ops, OpInsert)
+			i--
+		}
+	}
+
+	for l, r := 0, len(ops)-1; l < r; l, r = l+1, r-1 {
+		ops[l], ops[r] = ops[r], ops[l]
+	}
+	return ops
+}
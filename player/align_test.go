@@ -0,0 +1,75 @@
+package player
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestEditCounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		ref     string
+		pred    string
+		openEnd bool
+		want    EditCounts
+	}{
+		{"exact", "hello world", "hello world", false, EditCounts{}},
+		{"nothing typed", "hello", "", false, EditCounts{Deletions: 5}},
+		{"nothing typed, open end", "hello", "", true, EditCounts{}},
+		{"submitted early", "hello world", "hello", true, EditCounts{}},
+		{"submitted early, closed end", "hello world", "hello", false, EditCounts{Deletions: 6}},
+		{"typo", "hello", "hallo", false, EditCounts{Substitutions: 1}},
+		{"extra letter", "hello", "helllo", false, EditCounts{Insertions: 1}},
+		{"missed letter", "hello", "helo", false, EditCounts{Deletions: 1}},
+		{"typed past the end", "hi", "hi there", true, EditCounts{Insertions: 6}},
+		{"swapped letters, open end", "the quick brown fox", "teh quick", true, EditCounts{Substitutions: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := editCounts([]rune(tt.ref), []rune(tt.pred), tt.openEnd)
+			if got != tt.want {
+				t.Errorf("editCounts(%q, %q) = %+v, want %+v", tt.ref, tt.pred, got, tt.want)
+			}
+		})
+	}
+}
+
+// editCounts has to break ties the way align's backtrace does, or scores
+// would change with the diff view.
+func TestEditCountsMatchesAlign(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	text := func(n int) []rune {
+		s := make([]rune, n)
+		for i := range s {
+			s[i] = rune('a' + r.Intn(4))
+		}
+		return s
+	}
+
+	for range 2000 {
+		ref, pred := text(r.Intn(12)), text(r.Intn(12))
+		for _, openEnd := range []bool{false, true} {
+			want := countEdits(align(ref, pred, openEnd))
+			if got := editCounts(ref, pred, openEnd); got != want {
+				t.Fatalf("editCounts(%q, %q, %v) = %+v, align counts %+v", string(ref), string(pred), openEnd, got, want)
+			}
+		}
+	}
+}
+
+func BenchmarkEditCounts(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	ref := make([]rune, 2000)
+	for i := range ref {
+		ref[i] = rune('a' + r.Intn(26))
+	}
+	pred := append([]rune(nil), ref[:1900]...)
+	for i := 0; i < len(pred); i += 37 {
+		pred[i] = 'x'
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		editCounts(ref, pred, true)
+	}
+}
@@ -0,0 +1,232 @@
+package player
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"ssh-battle/data"
+)
+
+// A pause longer than this between two keys is the player stopping to read,
+// not how long the letter pair takes to type.
+const maxBigramGap = 2 * time.Second
+
+// KeyStat is how often a key was due and how often something else was typed
+// instead.
+type KeyStat struct {
+	Key     rune
+	Presses int
+	Errors  int
+}
+
+// ErrorRate is the percentage of presses that were wrong.
+func (k KeyStat) ErrorRate() float64 {
+	if k.Presses == 0 {
+		return 0
+	}
+	return float64(k.Errors) / float64(k.Presses) * 100
+}
+
+// BigramStat is the time taken between the two letters of a pair, counted
+// only when both were typed correctly one after the other.
+type BigramStat struct {
+	Bigram  string
+	Samples int
+	Total   time.Duration
+}
+
+func (b BigramStat) Average() time.Duration {
+	if b.Samples == 0 {
+		return 0
+	}
+	return b.Total / time.Duration(b.Samples)
+}
+
+// Analytics is a player's per key error rates and per letter pair latency.
+// Keys are lower case, so a missed capital counts against its letter.
+type Analytics struct {
+	Keys    map[rune]KeyStat
+	Bigrams map[string]BigramStat
+}
+
+func NewAnalytics() Analytics {
+	return Analytics{Keys: map[rune]KeyStat{}, Bigrams: map[string]BigramStat{}}
+}
+
+// Add folds one attempt at ref into a. Every character entered is checked
+// against ref at its position, so retyping after a backspace counts again.
+func (a Analytics) Add(ref string, keys KeystrokeLog) {
+	refChars := []rune(ref)
+	n := 0 // characters currently typed
+	prevOK := false
+	var prevAt time.Duration
+
+	for _, k := range keys.Keys {
+		if k.Rune == Backspace {
+			n = max(n-1, 0)
+			prevOK = false
+			continue
+		}
+
+		pos := n
+		n++
+		// Nothing was pressed for characters entered for the player
+		if pos >= len(refChars) || k.Auto {
+			prevOK = false
+			continue
+		}
+
+		want := unicode.ToLower(refChars[pos])
+		ok := k.Rune == refChars[pos]
+		stat := a.Keys[want]
+		stat.Key = want
+		stat.Presses++
+		if !ok {
+			stat.Errors++
+		}
+		a.Keys[want] = stat
+
+		// Pairs across a space measure reaching for the next word, skip them
+		prev := unicode.ToLower(refChars[max(pos-1, 0)])
+		if ok && prevOK && k.At-prevAt <= maxBigramGap && !unicode.IsSpace(prev) && !unicode.IsSpace(want) {
+			bigram := string([]rune{prev, want})
+			b := a.Bigrams[bigram]
+			b.Bigram = bigram
+			b.Samples++
+			b.Total += k.At - prevAt
+			a.Bigrams[bigram] = b
+		}
+		prevOK, prevAt = ok, k.At
+	}
+}
+
+// WeakKeys returns the keys pressed at least minPresses times, most
+// error-prone first.
+func (a Analytics) WeakKeys(minPresses int) []KeyStat {
+	var keys []KeyStat
+	for _, k := range a.Keys {
+		if k.Presses >= minPresses {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].ErrorRate() != keys[j].ErrorRate() {
+			return keys[i].ErrorRate() > keys[j].ErrorRate()
+		}
+		return keys[i].Key < keys[j].Key
+	})
+	return keys
+}
+
+// SlowBigrams returns the letter pairs seen at least minSamples times,
+// slowest on average first.
+func (a Analytics) SlowBigrams(minSamples int) []BigramStat {
+	var bigrams []BigramStat
+	for _, b := range a.Bigrams {
+		if b.Samples >= minSamples {
+			bigrams = append(bigrams, b)
+		}
+	}
+	sort.Slice(bigrams, func(i, j int) bool {
+		if bigrams[i].Average() != bigrams[j].Average() {
+			return bigrams[i].Average() > bigrams[j].Average()
+		}
+		return bigrams[i].Bigram < bigrams[j].Bigram
+	})
+	return bigrams
+}
+
+// Analytics returns the player's stats over all their saved runs. Guests
+// only have the runs from this session.
+func (p *Player) Analytics() (Analytics, error) {
+	a := NewAnalytics()
+	if p.Guest {
+		for _, s := range p.Scores {
+			if s.Sentence != nil && s.Keys != nil {
+				a.Add(*s.Sentence, *s.Keys)
+			}
+		}
+		return a, nil
+	}
+
+	keys, err := data.Repo.Analytics().Keys(p.ID)
+	if err != nil {
+		return a, err
+	}
+	for _, k := range keys {
+		r, _ := utf8.DecodeRuneInString(k.Key)
+		a.Keys[r] = KeyStat{Key: r, Presses: k.Presses, Errors: k.Errors}
+	}
+
+	bigrams, err := data.Repo.Analytics().Bigrams(p.ID)
+	if err != nil {
+		return a, err
+	}
+	for _, b := range bigrams {
+		a.Bigrams[b.Bigram] = BigramStat{Bigram: b.Bigram, Samples: b.Samples, Total: time.Duration(b.TotalMS) * time.Millisecond}
+	}
+	return a, nil
+}
+
+// saveAnalytics adds one run's stats to the player's stored totals.
+func saveAnalytics(playerID int, a Analytics) error {
+	if len(a.Keys) == 0 {
+		return nil
+	}
+
+	keys := make([]data.KeyStatRecord, 0, len(a.Keys))
+	for _, k := range a.Keys {
+		keys = append(keys, data.KeyStatRecord{Key: string(k.Key), Presses: k.Presses, Errors: k.Errors})
+	}
+	bigrams := make([]data.BigramStatRecord, 0, len(a.Bigrams))
+	for _, b := range a.Bigrams {
+		bigrams = append(bigrams, data.BigramStatRecord{Bigram: b.Bigram, Samples: b.Samples, TotalMS: b.Total.Milliseconds()})
+	}
+	return data.Repo.Analytics().Add(playerID, keys, bigrams)
+}
+
+// PracticeFocus is the keys and letter pairs a practice sentence drills.
+type PracticeFocus struct {
+	Keys    []KeyStat
+	Bigrams []BigramStat
+}
+
+// Focus picks up to n of the most missed keys and n of the slowest letter
+// pairs. Keys that were never missed aren't worth drilling and are left out.
+func (a Analytics) Focus(n, minPresses, minSamples int) PracticeFocus {
+	var f PracticeFocus
+	for _, k := range a.WeakKeys(minPresses) {
+		if len(f.Keys) == n || k.Errors == 0 {
+			break
+		}
+		// Words are picked by their letters, spaces are everywhere anyway
+		if !unicode.IsSpace(k.Key) {
+			f.Keys = append(f.Keys, k)
+		}
+	}
+	bigrams := a.SlowBigrams(minSamples)
+	f.Bigrams = bigrams[:min(n, len(bigrams))]
+	return f
+}
+
+func (f PracticeFocus) Empty() bool {
+	return len(f.Keys) == 0 && len(f.Bigrams) == 0
+}
+
+// Weight is how much more likely word should be picked for practice. Every
+// weak key or slow pair in it raises the weight, words without any stay at 1.
+func (f PracticeFocus) Weight(word string) float64 {
+	word = strings.ToLower(word)
+	w := 1.0
+	for _, k := range f.Keys {
+		w += 2 * float64(strings.Count(word, string(k.Key)))
+	}
+	for _, b := range f.Bigrams {
+		w += 3 * float64(strings.Count(word, b.Bigram))
+	}
+	// Squared so words hitting several targets clearly win out
+	return w * w
+}
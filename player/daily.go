@@ -0,0 +1,63 @@
+package player
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"ssh-battle/data"
+	"ssh-battle/util"
+)
+
+// DailyWords is how many words the daily challenge is.
+const DailyWords = 30
+
+// DailyOptions is what the daily challenge is generated with. It's the same
+// for everyone, whatever language and difficulty they play in otherwise.
+var DailyOptions = util.TextOptions{Language: util.DefaultLanguage, Difficulty: util.Normal}
+
+// DailyDay returns the UTC date (YYYY-MM-DD) of the daily challenge at now.
+func DailyDay(now time.Time) string {
+	return now.UTC().Format(time.DateOnly)
+}
+
+// DailyText is the day's challenge text. It's generated from the day's seed
+// the first time anyone asks for it and stored, so reloading the word lists
+// later that day doesn't change what everyone types.
+func DailyText(day string) (string, error) {
+	text, err := data.Repo.Daily().Text(day)
+	if !errors.Is(err, data.ErrNotFound) {
+		return text, err
+	}
+
+	seed := util.DailySeed(day)
+	words, err := util.NewGenerator(seed, DailyOptions).Words(DailyWords)
+	if err != nil {
+		return "", err
+	}
+	return data.Repo.Daily().SaveText(day, seed, strings.Join(words, " "))
+}
+
+// DailyUsed reports whether the player already started the day's ranked
+// attempt. Guests never have one.
+func (p *Player) DailyUsed(day string) (bool, error) {
+	if p.Guest {
+		return false, nil
+	}
+	return data.Repo.Daily().Used(p.ID, day)
+}
+
+// StartDaily uses up the day's ranked attempt as the text is shown, so it
+// can't be looked at first and attempted later. It reports whether this
+// attempt is ranked; guests' never are as their scores aren't saved.
+func (p *Player) StartDaily(day string) (bool, error) {
+	if p.Guest {
+		return false, nil
+	}
+	return data.Repo.Daily().Start(p.ID, day)
+}
+
+// FinishDaily puts the saved score of a ranked attempt on the day's board.
+func (p *Player) FinishDaily(day string, scoreID int) error {
+	return data.Repo.Daily().Finish(p.ID, day, scoreID)
+}
@@ -0,0 +1,117 @@
+package player
+
+import "strings"
+
+// DiffChar is one character step of a word typed differently from the target.
+type DiffChar struct {
+	Op EditOp
+	// Zero for OpInsert
+	Ref rune
+	// Zero for OpDelete
+	Typed rune
+}
+
+// DiffWord is one word step of an attempt aligned against its sentence.
+type DiffWord struct {
+	Op EditOp
+	// Empty for OpInsert
+	Ref string
+	// Empty for OpDelete
+	Typed string
+	// Character alignment of Typed against Ref, only for OpSubstitute
+	Chars []DiffChar
+}
+
+// Diff lines up what was typed with the sentence, first by words so a
+// skipped or doubled word doesn't shift everything after it, then by
+// characters within each mistyped word.
+type Diff []DiffWord
+
+// Compare aligns pred against ref. Words of ref that weren't reached count
+// as deleted.
+func Compare(ref, pred string) Diff {
+	refWords := strings.Fields(ref)
+	predWords := strings.Fields(pred)
+
+	// Aligning with an open end keeps a short attempt at the start of the
+	// sentence; the words it never reached are added as deleted afterwards
+	var diff Diff
+	i, j := 0, 0
+	for _, op := range align(refWords, predWords, true) {
+		w := DiffWord{Op: op}
+		switch op {
+		case OpMatch:
+			w.Ref, w.Typed = refWords[j], predWords[i]
+			i, j = i+1, j+1
+		case OpSubstitute:
+			w.Ref, w.Typed = refWords[j], predWords[i]
+			w.Chars = compareChars([]rune(w.Ref), []rune(w.Typed))
+			i, j = i+1, j+1
+		case OpInsert:
+			w.Typed = predWords[i]
+			i++
+		case OpDelete:
+			w.Ref = refWords[j]
+			j++
+		}
+		diff = append(diff, w)
+	}
+	for ; j < len(refWords); j++ {
+		diff = append(diff, DiffWord{Op: OpDelete, Ref: refWords[j]})
+	}
+	return diff
+}
+
+func compareChars(ref, pred []rune) []DiffChar {
+	var chars []DiffChar
+	i, j := 0, 0
+	for _, op := range align(ref, pred, false) {
+		c := DiffChar{Op: op}
+		switch op {
+		case OpMatch, OpSubstitute:
+			c.Ref, c.Typed = ref[j], pred[i]
+			i, j = i+1, j+1
+		case OpInsert:
+			c.Typed = pred[i]
+			i++
+		case OpDelete:
+			c.Ref = ref[j]
+			j++
+		}
+		chars = append(chars, c)
+	}
+	return chars
+}
+
+// Accuracy is the percentage of target characters typed correctly. Extra
+// words add their length to the total, so padding an attempt costs accuracy.
+func (d Diff) Accuracy() float64 {
+	total, correct := 0, 0
+	for _, w := range d {
+		switch w.Op {
+		case OpMatch:
+			n := len([]rune(w.Ref))
+			total += n
+			correct += n
+		case OpSubstitute:
+			total += len([]rune(w.Ref))
+			for _, c := range w.Chars {
+				switch c.Op {
+				case OpMatch:
+					correct++
+				case OpInsert:
+					total++
+				}
+			}
+		case OpInsert:
+			total += len([]rune(w.Typed))
+		case OpDelete:
+			total += len([]rune(w.Ref))
+		}
+	}
+
+	if total == 0 {
+		return 0
+	}
+	return float64(correct) / float64(total) * 100
+}
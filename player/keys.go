@@ -0,0 +1,101 @@
+package player
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+
+	"ssh-battle/data"
+)
+
+var (
+	ErrKeyExists   = errors.New("key is already registered")
+	ErrKeyNotFound = errors.New("key not found")
+)
+
+type PublicKey struct {
+	ID          int
+	Fingerprint string
+	Type        string
+	Comment     string
+	CreatedAt   time.Time
+}
+
+// CheckPublicKey reports whether key is registered to username.
+func CheckPublicKey(username string, key gossh.PublicKey) bool {
+	registered, err := data.Repo.Keys().Registered(username, gossh.FingerprintSHA256(key))
+	if err != nil {
+		log.Println("CheckPublicKey DB error:", err)
+		return false
+	}
+	return registered
+}
+
+func ListKeys(playerID int) ([]PublicKey, error) {
+	records, err := data.Repo.Keys().List(playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]PublicKey, len(records))
+	for i, r := range records {
+		keys[i] = PublicKey{
+			ID:          r.ID,
+			Fingerprint: r.Fingerprint,
+			Comment:     r.Comment,
+			CreatedAt:   r.CreatedAt,
+		}
+		keys[i].Type, _, _ = strings.Cut(r.PublicKey, " ")
+	}
+	return keys, nil
+}
+
+// AddKey registers a key in authorized_keys format ("ssh-ed25519 AAAA... comment")
+// on the player's account.
+func AddKey(playerID int, authorizedKey string) (PublicKey, error) {
+	pub, comment, _, _, err := gossh.ParseAuthorizedKey([]byte(authorizedKey))
+	if err != nil {
+		return PublicKey{}, err
+	}
+
+	key := PublicKey{
+		Fingerprint: gossh.FingerprintSHA256(pub),
+		Type:        pub.Type(),
+		Comment:     comment,
+		CreatedAt:   time.Now(),
+	}
+
+	key.ID, err = data.Repo.Keys().Add(data.KeyRecord{
+		PlayerID:    playerID,
+		Fingerprint: key.Fingerprint,
+		PublicKey:   strings.TrimSpace(string(gossh.MarshalAuthorizedKey(pub))),
+		Comment:     comment,
+		CreatedAt:   key.CreatedAt.UTC(),
+	})
+	if err == data.ErrConflict {
+		return PublicKey{}, ErrKeyExists
+	}
+	if err != nil {
+		return PublicKey{}, err
+	}
+
+	log.Printf("Player with id %d added key %s", playerID, key.Fingerprint)
+	return key, nil
+}
+
+// RevokeKey removes a key from the player's account by its fingerprint.
+func RevokeKey(playerID int, fingerprint string) error {
+	err := data.Repo.Keys().Delete(playerID, fingerprint)
+	if err == data.ErrNotFound {
+		return ErrKeyNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Player with id %d revoked key %s", playerID, fingerprint)
+	return nil
+}
@@ -0,0 +1,270 @@
+package player
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+	"unicode/utf8"
+
+	"ssh-battle/data"
+	"ssh-battle/util"
+)
+
+var ErrReplayNotFound = errors.New("replay not found")
+
+// Backspace is the Rune of a keystroke that deleted the character before the cursor.
+const Backspace = '\b'
+
+// Keystroke is one key pressed while typing a sentence.
+type Keystroke struct {
+	// Time since the sentence was shown
+	At time.Duration
+	// The typed character, or Backspace
+	Rune rune
+	// Entered for the player instead of typed, like the indentation after a
+	// line break in code. It moves the cursor but isn't a key press.
+	Auto bool
+}
+
+// KeystrokeLog is everything a player typed during one attempt, in order.
+type KeystrokeLog struct {
+	Keys []Keystroke
+	// Time from the sentence being shown to the attempt ending
+	Elapsed time.Duration
+}
+
+// Text replays the log and returns what the player submitted.
+func (l KeystrokeLog) Text() string {
+	typed := make([]rune, 0, len(l.Keys))
+	for _, k := range l.Keys {
+		if k.Rune != Backspace {
+			typed = append(typed, k.Rune)
+		} else if len(typed) > 0 {
+			typed = typed[:len(typed)-1]
+		}
+	}
+	return string(typed)
+}
+
+// Backspaces counts the corrections made during the attempt.
+func (l KeystrokeLog) Backspaces() int {
+	n := 0
+	for _, k := range l.Keys {
+		if k.Rune == Backspace {
+			n++
+		}
+	}
+	return n
+}
+
+// typed counts the keystrokes that entered a character, leaving out the
+// ones entered for the player.
+func (l KeystrokeLog) typed() int {
+	n := 0
+	for _, k := range l.Keys {
+		if k.Rune != Backspace && !k.Auto {
+			n++
+		}
+	}
+	return n
+}
+
+// CorrectedErrors counts wrong characters that were deleted again before
+// submitting. A character is wrong if it doesn't match ref at its position.
+func (l KeystrokeLog) CorrectedErrors(ref string) int {
+	refChars := []rune(ref)
+	var wrong []bool // one per character currently typed
+	corrected := 0
+	for _, k := range l.Keys {
+		if k.Rune != Backspace {
+			pos := len(wrong)
+			wrong = append(wrong, pos >= len(refChars) || refChars[pos] != k.Rune)
+			continue
+		}
+		if len(wrong) > 0 {
+			if wrong[len(wrong)-1] {
+				corrected++
+			}
+			wrong = wrong[:len(wrong)-1]
+		}
+	}
+	return corrected
+}
+
+// WPMSamples returns the raw WPM of every second of the attempt. A last
+// partial second under half a second is folded into the one before it.
+func (l KeystrokeLog) WPMSamples() []float64 {
+	secs := l.Elapsed.Seconds()
+	if secs <= 0 {
+		return nil
+	}
+
+	buckets := int(math.Ceil(secs))
+	lastLen := secs - float64(buckets-1)
+	if buckets > 1 && lastLen < 0.5 {
+		buckets--
+		lastLen += 1
+	}
+
+	chars := make([]int, buckets)
+	for _, k := range l.Keys {
+		if k.Rune == Backspace || k.Auto {
+			continue
+		}
+		chars[min(int(k.At.Seconds()), buckets-1)]++
+	}
+
+	samples := make([]float64, buckets)
+	for i, n := range chars {
+		length := 1.0
+		if i == buckets-1 {
+			length = lastLen
+		}
+		samples[i] = float64(n) / 5 / (length / 60)
+	}
+	return samples
+}
+
+// Consistency rates how steady the samples are from 0 to 100, 100 being
+// the same speed throughout.
+func Consistency(samples []float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+
+	mean := 0.0
+	for _, s := range samples {
+		mean += s
+	}
+	mean /= float64(len(samples))
+	if mean == 0 {
+		return 0
+	}
+
+	variance := 0.0
+	for _, s := range samples {
+		variance += (s - mean) * (s - mean)
+	}
+	stddev := math.Sqrt(variance / float64(len(samples)))
+
+	return max(0, 100*(1-stddev/mean))
+}
+
+// storedKey is a keystroke as saved in the database, times in milliseconds.
+type storedKey struct {
+	At   int64  `json:"t"`
+	Rune string `json:"k"`
+	Auto bool   `json:"a,omitempty"`
+}
+
+type storedLog struct {
+	Elapsed int64       `json:"elapsed"`
+	Keys    []storedKey `json:"keys"`
+}
+
+func encodeKeystrokes(l KeystrokeLog) (string, error) {
+	stored := storedLog{Elapsed: l.Elapsed.Milliseconds(), Keys: make([]storedKey, len(l.Keys))}
+	for i, k := range l.Keys {
+		stored.Keys[i] = storedKey{At: k.At.Milliseconds(), Rune: string(k.Rune), Auto: k.Auto}
+	}
+	b, err := json.Marshal(stored)
+	return string(b), err
+}
+
+func decodeKeystrokes(s string) (KeystrokeLog, error) {
+	var stored storedLog
+	if err := json.Unmarshal([]byte(s), &stored); err != nil {
+		return KeystrokeLog{}, err
+	}
+
+	l := KeystrokeLog{Elapsed: time.Duration(stored.Elapsed) * time.Millisecond, Keys: make([]Keystroke, 0, len(stored.Keys))}
+	for _, k := range stored.Keys {
+		r, _ := utf8.DecodeRuneInString(k.Rune)
+		l.Keys = append(l.Keys, Keystroke{At: time.Duration(k.At) * time.Millisecond, Rune: r, Auto: k.Auto})
+	}
+	return l, nil
+}
+
+// Replay is a saved run that can be played back key by key.
+type Replay struct {
+	ScoreID    int
+	PlayerName string
+	Sentence   string
+	Keys       KeystrokeLog
+	// The run's mode and text source, a race against it is saved under them
+	Mode   Mode
+	Source string
+	// What Sentence was generated with, so a race against it scores the same
+	Difficulty util.Difficulty
+	Language   string
+	// What Sentence was generated from, 0 if it can't be generated again
+	Seed      int64
+	CreatedAt time.Time
+}
+
+// HasReplay reports whether the score was saved with keystrokes to replay.
+func (s Score) HasReplay() bool {
+	return s.ID != nil && s.Sentence != nil && s.Keys != nil && len(s.Keys.Keys) > 0
+}
+
+// LoadReplay returns ErrReplayNotFound for scores saved without keystrokes.
+func LoadReplay(scoreID int) (Replay, error) {
+	return toReplay(data.Repo.Scores().Replay(scoreID))
+}
+
+// BestReplay returns the replay of the player's best ranked run in mode with
+// text from source, empty meaning ModeSentence and "words" like on Score.
+// Guests only have the runs from this session.
+func (p *Player) BestReplay(mode Mode, source string) (Replay, error) {
+	// Saved the way SaveScore saves them
+	want := Score{Mode: mode, Source: source}.record(p.ID)
+	if !p.Guest {
+		return toReplay(data.Repo.Scores().BestReplay(p.ID, want.Mode, want.Source))
+	}
+
+	var best *Score
+	for i, s := range p.Scores {
+		if r := s.record(p.ID); !r.Ranked || r.Mode != want.Mode || r.Source != want.Source {
+			continue
+		}
+		if s.Sentence != nil && s.Keys != nil && len(s.Keys.Keys) > 0 && (best == nil || *s.TP > *best.TP) {
+			best = &p.Scores[i]
+		}
+	}
+	if best == nil {
+		return Replay{}, ErrReplayNotFound
+	}
+	replay := Replay{PlayerName: p.Name, Sentence: *best.Sentence, Keys: *best.Keys, Mode: best.Mode, Source: best.Source, Difficulty: best.Difficulty, Language: best.Language, Seed: best.Seed}
+	if best.CreatedAt != nil {
+		replay.CreatedAt = *best.CreatedAt
+	}
+	return replay, nil
+}
+
+func toReplay(rec data.ReplayRecord, err error) (Replay, error) {
+	if err == data.ErrNotFound {
+		return Replay{}, ErrReplayNotFound
+	}
+	if err != nil {
+		return Replay{}, err
+	}
+
+	keys, err := decodeKeystrokes(rec.Keystrokes)
+	if err != nil {
+		return Replay{}, err
+	}
+	difficulty, _ := util.ParseDifficulty(rec.Difficulty)
+	return Replay{
+		ScoreID:    rec.ScoreID,
+		PlayerName: rec.Username,
+		Sentence:   rec.Sentence,
+		Keys:       keys,
+		Mode:       Mode(rec.Mode),
+		Source:     rec.Source,
+		Difficulty: difficulty,
+		Language:   rec.Language,
+		Seed:       rec.Seed,
+		CreatedAt:  rec.CreatedAt,
+	}, nil
+}
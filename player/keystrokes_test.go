@@ -0,0 +1,161 @@
+package player
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// typeEvenly logs typed as keys spread evenly over elapsed, the first one
+// after a gap. '\b' in typed is a Backspace.
+func typeEvenly(typed string, elapsed time.Duration) KeystrokeLog {
+	runes := []rune(typed)
+	l := KeystrokeLog{Elapsed: elapsed}
+	for i, r := range runes {
+		l.Keys = append(l.Keys, Keystroke{At: elapsed * time.Duration(i+1) / time.Duration(len(runes)+1), Rune: r})
+	}
+	return l
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestKeystrokeLogText(t *testing.T) {
+	tests := []struct {
+		typed string
+		want  string
+	}{
+		{"", ""},
+		{"hello", "hello"},
+		{"ha\bello", "hello"},
+		{"\b\bhi", "hi"},
+		{"héllo\b\b\b\b", "h"},
+	}
+	for _, tt := range tests {
+		l := typeEvenly(tt.typed, time.Second)
+		if got := l.Text(); got != tt.want {
+			t.Errorf("Text of %q = %q, want %q", tt.typed, got, tt.want)
+		}
+	}
+}
+
+func TestCorrectedErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		typed string
+		want  int
+	}{
+		{"no mistakes", "hello", 0},
+		{"mistake left in", "hallo", 0},
+		{"mistake fixed", "ha\bello", 1},
+		// Only the wrong character of the two deleted counts
+		{"right character deleted too", "helo\b\bllo", 1},
+		{"both deleted characters wrong", "hexx\b\bllo", 2},
+		{"typed past the end and deleted", "hello!!\b\b", 2},
+		{"backspace with nothing typed", "\bhello", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := typeEvenly(tt.typed, time.Second)
+			if got := l.CorrectedErrors("hello"); got != tt.want {
+				t.Errorf("CorrectedErrors(%q) = %d, want %d", tt.typed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWPMSamples(t *testing.T) {
+	// Ten keys a second, half way through each tenth
+	steady := func(n int, elapsed time.Duration) KeystrokeLog {
+		l := KeystrokeLog{Elapsed: elapsed}
+		for i := range n {
+			l.Keys = append(l.Keys, Keystroke{At: time.Duration(i)*100*time.Millisecond + 50*time.Millisecond, Rune: 'a'})
+		}
+		return l
+	}
+
+	tests := []struct {
+		name string
+		keys KeystrokeLog
+		want []float64
+	}{
+		{"nothing elapsed", KeystrokeLog{}, nil},
+		{"whole seconds", steady(30, 3*time.Second), []float64{120, 120, 120}},
+		// 6 characters in the last 0.6s
+		{"long last second", steady(36, 3600*time.Millisecond), []float64{120, 120, 120, 6 * 60 / 5 / 0.6}},
+		// 10 characters in the last 1.3s
+		{"short last second folded in", steady(30, 3300*time.Millisecond), []float64{120, 120, 10 * 60 / 5 / 1.3}},
+		{"backspaces don't count", KeystrokeLog{Elapsed: time.Second, Keys: []Keystroke{
+			{At: 100 * time.Millisecond, Rune: 'a'},
+			{At: 200 * time.Millisecond, Rune: Backspace},
+			{At: 300 * time.Millisecond, Rune: 'b'},
+		}}, []float64{24}},
+		{"indentation entered for the player doesn't count", KeystrokeLog{Elapsed: time.Second, Keys: []Keystroke{
+			{At: 100 * time.Millisecond, Rune: '\n'},
+			{At: 100 * time.Millisecond, Rune: ' ', Auto: true},
+			{At: 100 * time.Millisecond, Rune: ' ', Auto: true},
+			{At: 300 * time.Millisecond, Rune: 'b'},
+		}}, []float64{24}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.keys.WPMSamples()
+			if len(got) != len(tt.want) {
+				t.Fatalf("WPMSamples = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !near(got[i], tt.want[i]) {
+					t.Fatalf("WPMSamples = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestConsistency(t *testing.T) {
+	tests := []struct {
+		samples []float64
+		want    float64
+	}{
+		{nil, 0},
+		{[]float64{0, 0}, 0},
+		{[]float64{60, 60, 60}, 100},
+		// Mean 60, standard deviation 30
+		{[]float64{30, 90}, 50},
+		// Standard deviation over the mean is clamped
+		{[]float64{0, 0, 0, 120}, 0},
+	}
+	for _, tt := range tests {
+		if got := Consistency(tt.samples); !near(got, tt.want) {
+			t.Errorf("Consistency(%v) = %v, want %v", tt.samples, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeKeystrokes(t *testing.T) {
+	l := KeystrokeLog{Elapsed: 2500 * time.Millisecond, Keys: []Keystroke{
+		{At: 120 * time.Millisecond, Rune: 'h'},
+		{At: 340 * time.Millisecond, Rune: 'é'},
+		{At: 800 * time.Millisecond, Rune: Backspace},
+		{At: 1200 * time.Millisecond, Rune: '\n'},
+		{At: 1200 * time.Millisecond, Rune: ' ', Auto: true},
+		{At: 1500 * time.Millisecond, Rune: '世'},
+	}}
+	s, err := encodeKeystrokes(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodeKeystrokes(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, l) {
+		t.Errorf("decodeKeystrokes(%s) = %+v, want %+v", s, got, l)
+	}
+
+	if _, err := decodeKeystrokes("not json"); err == nil {
+		t.Error("decodeKeystrokes of bad JSON didn't fail")
+	}
+}
@@ -0,0 +1,94 @@
+package player
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Mode is a single player format. It's saved with every score so each mode
+// gets its own leaderboard.
+type Mode string
+
+// One random sentence, finished whenever the player is done. Duos and
+// practice runs are saved as this too.
+const ModeSentence Mode = "sentence"
+
+// The daily challenge, the same text for everyone each UTC day. It's played
+// from its own scene and ranked on its own board, so it isn't in Modes.
+const ModeDaily Mode = "daily"
+
+// TimedMode is a run against the clock over an endless stream of words.
+func TimedMode(seconds int) Mode {
+	return Mode(fmt.Sprintf("time:%d", seconds))
+}
+
+// WordsMode is a run of a fixed number of words.
+func WordsMode(words int) Mode {
+	return Mode(fmt.Sprintf("words:%d", words))
+}
+
+// Modes lists every mode that can be played and ranked, in menu order.
+var Modes = []Mode{
+	ModeSentence,
+	TimedMode(15), TimedMode(30), TimedMode(60), TimedMode(120),
+	WordsMode(10), WordsMode(25), WordsMode(50), WordsMode(100),
+}
+
+// Seconds is the time limit of a timed mode, 0 for other modes.
+func (m Mode) Seconds() int {
+	return m.count("time:")
+}
+
+// Words is the length of a word count mode, 0 for other modes.
+func (m Mode) Words() int {
+	return m.count("words:")
+}
+
+func (m Mode) count(prefix string) int {
+	s, ok := strings.CutPrefix(string(m), prefix)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+func (m Mode) String() string {
+	switch {
+	case m.Seconds() > 0:
+		return fmt.Sprintf("%ds", m.Seconds())
+	case m.Words() > 0:
+		return fmt.Sprintf("%d words", m.Words())
+	case m == ModeDaily:
+		return "Daily"
+	default:
+		return "Sentence"
+	}
+}
+
+// ParseMode accepts names like "sentence", "30s" and "25w" for the modes in
+// Modes.
+func ParseMode(s string) (Mode, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "sentence", "classic", "default":
+		return ModeSentence, true
+	}
+
+	var mode Mode
+	if n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSuffix(s, "seconds"), "s")); err == nil {
+		mode = TimedMode(n)
+	} else if n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSuffix(s, "words"), "w")); err == nil {
+		mode = WordsMode(n)
+	} else {
+		mode = Mode(s)
+	}
+
+	for _, m := range Modes {
+		if m == mode {
+			return m, true
+		}
+	}
+	return ModeSentence, false
+}
@@ -1,190 +1,147 @@
 package player
 
 import (
-	"database/sql"
 	"log"
 	"strings"
-	"sync"
-	"time"
 
 	glider "github.com/gliderlabs/ssh"
 	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/term"
 
 	"ssh-battle/data"
+	"ssh-battle/util"
 )
 
-var mu sync.Mutex
+// AllowGuests lets names without an account play without registering.
+// Guest scores are kept for the session only.
+var AllowGuests = false
+
+// Admins are the usernames allowed to run admin commands.
+var Admins []string
+
+type contextKey struct{ name string }
+
+// ContextKeyAuth holds the AuthMethod the SSH connection was accepted with.
+var ContextKeyAuth = &contextKey{"auth"}
+
+type AuthMethod string
+
+const (
+	AuthPassword  AuthMethod = "password"
+	AuthPublicKey AuthMethod = "publickey"
+	// AuthPending is an unregistered name that still has to register (or join as a guest)
+	AuthPending AuthMethod = "pending"
+)
 
 type Player struct {
-	ID       int
-	Name     string
+	ID    int
+	Name  string
+	Guest bool
+	// Scores from this session, history is in the stats package
 	Scores   []Score
 	Session  glider.Session
 	Messages chan string
 	Ready    bool
-
-	Shell  *term.Terminal       
-	WinCh  <-chan glider.Window 	
-	PtyReq *glider.Pty          
+	// Single player mode picked last this session, ModeSentence if empty
+	Mode Mode
+	// Text source picked last this session, "words" if empty
+	Source string
+	// Generated text options picked last this session, Easy if zero
+	Difficulty util.Difficulty
+	// Language code words are generated in, saved for registered players
+	Language string
+
+	Shell  *term.Terminal
+	WinCh  <-chan glider.Window
+	PtyReq *glider.Pty
 }
 
-func playerExists(username string) (bool, error) {
-	var exists bool
-	err := data.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM players WHERE username = ?)", username).Scan(&exists)
-	return exists, err
+// IsRegistered reports whether username has an account with a password set.
+func IsRegistered(username string) (bool, error) {
+	return data.Repo.Players().IsRegistered(username)
 }
 
 func CheckPassword(username, password string) bool {
-	var hash sql.NullString
-	err := data.DB.QueryRow("SELECT password_hash FROM players WHERE username = ?", username).Scan(&hash)
-	if err == sql.ErrNoRows {
-		// User not found, allow login
-		return true
+	record, err := data.Repo.Players().Get(username)
+	if err == data.ErrNotFound {
+		// Not an account, registration is handled after login
+		return false
 	}
 	if err != nil {
 		log.Println("CheckPassword DB error:", err)
 		return false
 	}
-	// If password hash is NULL, allow login (no password set)
-	if !hash.Valid {
-		return true
+	// Rows without a hash never finished registering and can't be logged into
+	if record.PasswordHash == "" {
+		return false
 	}
 	// Compare password with hash
-	err = bcrypt.CompareHashAndPassword([]byte(hash.String), []byte(password))
+	err = bcrypt.CompareHashAndPassword([]byte(record.PasswordHash), []byte(password))
 	return err == nil
 }
 
-func CreateNewPassword(s glider.Session) {
-	shell := term.NewTerminal(s, "> ")
-
-	for {
-		shell.Write([]byte("You don't have a password, create one:\n"))
-		pass, err := shell.ReadPassword("Password(Or enter when new user): ")
-		if err != nil {
-			return
-		}
-		pass = strings.TrimSpace(pass)
-
-		shell.Write([]byte("Confirm password:\n"))
-		confPass, err := shell.ReadPassword("Password(Or enter when new user): ")
-		if err != nil {
-			return
-		}
-		confPass = strings.TrimSpace(confPass)
-
-		if pass != confPass {
-			shell.Write([]byte("Passwords do not match. Try again.\n\n"))
-			continue
-		}
-
-		hash, err := HashPassword(pass)
-		if err != nil {
-			shell.Write([]byte("Internal error. Try again.\n"))
-			continue
-		}
-
-		_, err = data.DB.Exec("INSERT OR REPLACE INTO players (username, password_hash) VALUES (?, ?)", s.User(), hash)
-		if err != nil {
-			log.Println("DB insert error:", err)
-			shell.Write([]byte("Failed to save password. Try again later.\n"))
-			return
-		}
-
-		log.Printf("%s Has set a new password", s.User())
-		shell.Write([]byte("Password set! You are now logged in.\n"))
-		break
-	}
-}
-
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	return string(bytes), err
 }
 
-func getPasswordHash(username string) (sql.NullString, error) {
-	var hash sql.NullString
-	err := data.DB.QueryRow("SELECT password_hash FROM players WHERE username = ?", username).Scan(&hash)
-	return hash, err
-}
+// Login turns an authenticated session into a Player. Sessions that passed a
+// password or key check load their account, pending ones go through Register.
+func Login(s glider.Session) *Player {
+	method, _ := s.Context().Value(ContextKeyAuth).(AuthMethod)
 
-func GetOrCreatePlayer(s glider.Session) *Player {
-	mu.Lock()
-	defer mu.Unlock()
-
-	name := s.User()
-
-	exists, err := playerExists(name)
-	if err != nil {
-		log.Println("DB error checking player existence:", err)
-		return nil
-	}
-
-	if !exists {
-		_, err := data.DB.Exec("INSERT INTO players (username) VALUES (?)", name)
-		if err != nil {
-			log.Println("DB error inserting new player:", err)
-			return nil
-		}
-	}
-
-	// Check if password_hash is NULL (no password set)
-	passHash, err := getPasswordHash(name)
-	if err != nil {
-		log.Println("DB error getting password hash:", err)
+	switch method {
+	case AuthPassword, AuthPublicKey:
+		return loadPlayer(s.User())
+	case AuthPending:
+		return Register(s)
+	default:
+		log.Printf("Session for %s has no auth method", s.User())
 		return nil
 	}
+}
 
-	if !passHash.Valid {
-		// password_hash is NULL → force password creation before login
-		CreateNewPassword(s)
-	}
-
+func loadPlayer(name string) *Player {
 	// Retrieve player id and username
-	var id int
-	err = data.DB.QueryRow("SELECT id, username FROM players WHERE username = ?", name).Scan(&id, &name)
+	record, err := data.Repo.Players().Get(name)
 	if err != nil {
 		log.Println("DB error retrieving player:", err)
 		return nil
 	}
 
 	player := &Player{
-		ID:   id,
-		Name: name,
+		ID:       record.ID,
+		Name:     record.Username,
+		Language: record.Language,
 	}
 
 	player.Messages = make(chan string, 10)
 
-	// Assign player scores
-	scores, err := getScoresForPlayer(player.ID)
-	if err != nil {
-		log.Println("DB error retrieving scores:", err)
-	} else {
-		player.Scores = scores
-	}
-
 	return player
 }
 
-func getScoresForPlayer(playerID int) ([]Score, error) {
-	rows, err := data.DB.Query("SELECT id, accuracy, wpm, tp, duration, created_at FROM scores WHERE player_id = ?", playerID)
-	if err != nil {
-		return nil, err
+// IsAdmin reports whether the player is a registered account listed in
+// Admins. Guests never are, anyone can pick a guest name.
+func (p *Player) IsAdmin() bool {
+	if p.Guest {
+		return false
 	}
-	defer rows.Close()
-
-	var scores []Score
-	for rows.Next() {
-		var s Score
-		var createdAt time.Time
-		err := rows.Scan(&s.ID, &s.Accuracy, &s.WPM, &s.TP, &s.Duration, &createdAt)
-		if err != nil {
-			return nil, err
+	for _, name := range Admins {
+		if strings.EqualFold(name, p.Name) {
+			return true
 		}
-		s.CreatedAt = &createdAt
-		scores = append(scores, s)
 	}
-	return scores, nil
+	return false
+}
+
+// SetLanguage switches the language words are generated in. Registered
+// players keep it for their next sessions.
+func (p *Player) SetLanguage(language string) error {
+	p.Language = language
+	if p.Guest {
+		return nil
+	}
+	return data.Repo.Players().SetLanguage(p.ID, language)
 }
 
 func (p *Player) SendMessage(msg string) {
@@ -0,0 +1,140 @@
+package player
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"sync"
+
+	glider "github.com/gliderlabs/ssh"
+	"golang.org/x/term"
+
+	"ssh-battle/data"
+	"ssh-battle/util"
+)
+
+var ErrNameTaken = errors.New("username is already taken")
+
+// Names that are in the middle of registering. Nothing is written to the
+// database until the password is confirmed, so this is the only thing that
+// stops two connections from registering the same name at once.
+var pendingMu sync.Mutex
+var pendingRegistrations = make(map[string]bool)
+
+func beginRegistration(name string) bool {
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+
+	key := strings.ToLower(name)
+	if pendingRegistrations[key] {
+		return false
+	}
+	pendingRegistrations[key] = true
+	return true
+}
+
+func endRegistration(name string) {
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+	delete(pendingRegistrations, strings.ToLower(name))
+}
+
+// Register walks a pending session through creating an account, or lets it
+// join as a guest when AllowGuests is set. Returns nil if the session gave up
+// or the name was claimed by someone else first.
+func Register(s glider.Session) *Player {
+	name := s.User()
+	shell := term.NewTerminal(s, "> ")
+
+	if !beginRegistration(name) {
+		shell.Write([]byte("Someone is already registering this name. Try again later.\n"))
+		return nil
+	}
+	defer endRegistration(name)
+
+	shell.Write([]byte("The name " + name + " isn't registered yet.\n"))
+
+	if AllowGuests {
+		for {
+			shell.Write([]byte("Type 'register' to create an account or 'guest' to play without one.\n"))
+			choice, err := shell.ReadLine()
+			if err != nil {
+				return nil
+			}
+
+			choice = strings.ToLower(strings.TrimSpace(choice))
+			if choice == "guest" || choice == "g" {
+				log.Printf("%s joined as a guest", name)
+				return &Player{
+					Name:     name,
+					Guest:    true,
+					Language: util.DefaultLanguage,
+					Messages: make(chan string, 10),
+				}
+			}
+			if choice == "register" || choice == "r" {
+				break
+			}
+		}
+	}
+
+	for {
+		shell.Write([]byte("Create a password for your account:\n"))
+		pass, err := shell.ReadPassword("Password: ")
+		if err != nil {
+			return nil
+		}
+		pass = strings.TrimSpace(pass)
+
+		if pass == "" {
+			shell.Write([]byte("Password can't be empty. Try again.\n\n"))
+			continue
+		}
+
+		shell.Write([]byte("Confirm password:\n"))
+		confPass, err := shell.ReadPassword("Password: ")
+		if err != nil {
+			return nil
+		}
+		confPass = strings.TrimSpace(confPass)
+
+		if pass != confPass {
+			shell.Write([]byte("Passwords do not match. Try again.\n\n"))
+			continue
+		}
+
+		hash, err := HashPassword(pass)
+		if err != nil {
+			shell.Write([]byte("Internal error. Try again.\n"))
+			continue
+		}
+
+		err = createAccount(name, hash)
+		if err == ErrNameTaken {
+			shell.Write([]byte("Someone else registered this name first. Please reconnect with another name.\n"))
+			return nil
+		}
+		if err != nil {
+			log.Println("DB insert error:", err)
+			shell.Write([]byte("Failed to save password. Try again later.\n"))
+			return nil
+		}
+
+		log.Printf("%s registered a new account", name)
+		shell.Write([]byte("Account created! You are now logged in.\n"))
+		break
+	}
+
+	return loadPlayer(name)
+}
+
+// createAccount commits the account for name. Rows left without a password by
+// older versions are claimed in place, otherwise a new row is inserted and the
+// unique username decides who wins a race.
+func createAccount(name, hash string) error {
+	err := data.Repo.Players().Register(name, hash)
+	if err == data.ErrConflict {
+		return ErrNameTaken
+	}
+	return err
+}
@@ -3,100 +3,109 @@ package player
 import (
 	"log"
 	"math"
-	"strings"
 	"sync"
 	"time"
 
 	"ssh-battle/data"
+	"ssh-battle/util"
 )
 
 type Score struct {
 	ID        *int
 	PlayerID  *int
 	Accuracy  *float64
-	WPM       *float64
+	WPM       *float64 // Net WPM, raw WPM less a word a minute per uncorrected error
 	TP        *float64
 	Duration  *int
 	CreatedAt *time.Time
-}
 
-type LeaderboardEntry struct {
-	PlayerName string
-	Score      Score
+	// Every typed character counts toward raw WPM, mistakes included
+	RawWPM *float64
+	// Mistakes fixed with Backspace before submitting
+	CorrectedErrors *int
+	// Mistakes left in the submitted text, broken down below
+	UncorrectedErrors *int
+	Insertions        *int
+	Deletions         *int
+	Substitutions     *int
+	// Raw WPM of every second of the attempt
+	WPMSamples []float64
+	// How steady WPMSamples is, 0-100
+	Consistency *float64
+
+	// Practice runs are saved but don't count for the leaderboards
+	Practice bool
+	// ModeSentence if empty
+	Mode Mode
+	// Name of the text source, "words" if empty
+	Source string
+	// What the text was generated with, harder text is worth more TP
+	Difficulty util.Difficulty
+	// Language code of the text, util.DefaultLanguage if empty
+	Language string
+	// What the text was generated from, 0 if it can't be generated again
+	Seed int64
+
+	// What was typed, for replays. Nil for scores without a keystroke log.
+	Sentence *string
+	Keys     *KeystrokeLog
 }
 
-
-func ScoreCalculation(ref, pred string, elapsed time.Duration) Score {
-	refChars := []rune(ref)
-	predChars := []rune(pred)
-
-	// totalRefChars := len(refChars)
-	totalPredChars := len(predChars)
-
-	minLen := min(len(predChars), len(refChars))
-
-	correct := 0
-
-	for i := range minLen {
-		if refChars[i] == predChars[i] {
-			correct++
-		}
-	}
+// ScoreCalculation scores one attempt at typing ref from its keystroke log.
+// difficulty is what ref was generated with.
+func ScoreCalculation(ref string, keys KeystrokeLog, difficulty util.Difficulty) Score {
+	// Accents typed as a letter and a combining mark are composed as they're
+	// typed, so the target has to be composed the same way
+	ref = util.Compose(ref)
+	pred := keys.Text()
 
 	acc := AccuracyPerWord(ref, pred)
 
-	secs := elapsed.Seconds()
+	secs := keys.Elapsed.Seconds()
 	if secs == 0 {
 		secs = 1 // Avoid division by zero
 	}
-
-	wpm := (60.0 * float64(totalPredChars/5.0)) / secs
+	minutes := secs / 60
+
+	// Untyped text at the end is already costed by accuracy, so only count
+	// mistakes up to where the player stopped
+	edits := editCounts([]rune(ref), []rune(pred), true)
+	uncorrected := edits.Total()
+	corrected := keys.CorrectedErrors(ref)
+
+	rawWPM := float64(keys.typed()) / 5 / minutes
+	wpm := max(rawWPM-float64(uncorrected)/minutes, 0)
+	samples := keys.WPMSamples()
+	consistency := Consistency(samples)
 	d := int(secs)
 
-	tp := CalculateTP(acc, wpm, d)
+	tp := CalculateTP(acc, wpm, d, difficulty)
 	return Score{
-		Accuracy: &acc,
-		WPM:      &wpm,
-		Duration: &d,
-		TP:       &tp,
+		Accuracy:          &acc,
+		WPM:               &wpm,
+		Duration:          &d,
+		TP:                &tp,
+		RawWPM:            &rawWPM,
+		CorrectedErrors:   &corrected,
+		UncorrectedErrors: &uncorrected,
+		Insertions:        &edits.Insertions,
+		Deletions:         &edits.Deletions,
+		Substitutions:     &edits.Substitutions,
+		WPMSamples:        samples,
+		Consistency:       &consistency,
+		Difficulty:        difficulty,
+		Sentence:          &ref,
+		Keys:              &keys,
 	}
 }
 
+// AccuracyPerWord aligns the typed words with ref before comparing them,
+// so a missed or doubled word only costs its own characters.
 func AccuracyPerWord(ref, pred string) float64 {
-	refWords := strings.Fields(ref)
-	predWords := strings.Fields(pred)
-
-	totalChars := 0
-	correctChars := 0
-
-	minWords := min(len(refWords), len(predWords))
-
-	for i := range minWords {
-		refChars := []rune(refWords[i])
-		predChars := []rune(predWords[i])
-		minLen := min(len(refChars), len(predChars))
-
-		for j := range minLen {
-			if refChars[j] == predChars[j] {
-				correctChars++
-			}
-		}
-		totalChars += len(refChars)
-	}
-
-	// Account for extra words in ref (penalty for missing words)
-	for i := minWords; i < len(refWords); i++ {
-		totalChars += len([]rune(refWords[i]))
-	}
-
-	if totalChars == 0 {
-		return 0
-	}
-
-	return (float64(correctChars) / float64(totalChars)) * 100
+	return Compare(ref, pred).Accuracy()
 }
 
-func CalculateTP(accuracy float64, wpm float64, duration int) float64 {
+func CalculateTP(accuracy float64, wpm float64, duration int, difficulty util.Difficulty) float64 {
 	const accWeight = 1.2
 	const wpmWeight = 1.5
 	const timeWeight = 0.8
@@ -106,36 +115,100 @@ func CalculateTP(accuracy float64, wpm float64, duration int) float64 {
 
 	tp := (math.Pow(accuracy, accWeight) * math.Pow(wpm, wpmWeight)) / (durFactor * 1000)
 
+	// Capitals, punctuation, numbers and symbols slow everyone down
+	tp *= difficulty.Multiplier()
+
 	return tp
 }
 
+func (s Score) record(playerID int) data.ScoreRecord {
+	r := data.ScoreRecord{PlayerID: playerID, Ranked: !s.Practice, Mode: string(s.Mode)}
+	if s.Mode == "" {
+		r.Mode = string(ModeSentence)
+	}
+	r.Source = s.Source
+	if s.Source == "" {
+		r.Source = "words"
+	}
+	r.Difficulty = s.Difficulty.String()
+	r.Language = s.Language
+	if s.Language == "" {
+		r.Language = util.DefaultLanguage
+	}
+	r.Seed = s.Seed
+	if s.Accuracy != nil {
+		r.Accuracy = *s.Accuracy
+	}
+	if s.WPM != nil {
+		r.WPM = *s.WPM
+	}
+	if s.TP != nil {
+		r.TP = *s.TP
+	}
+	if s.Duration != nil {
+		r.Duration = *s.Duration
+	}
+	if s.RawWPM != nil {
+		r.RawWPM = *s.RawWPM
+	}
+	if s.CorrectedErrors != nil {
+		r.CorrectedErrors = *s.CorrectedErrors
+	}
+	if s.UncorrectedErrors != nil {
+		r.UncorrectedErrors = *s.UncorrectedErrors
+	}
+	if s.Insertions != nil {
+		r.Insertions = *s.Insertions
+	}
+	if s.Deletions != nil {
+		r.Deletions = *s.Deletions
+	}
+	if s.Substitutions != nil {
+		r.Substitutions = *s.Substitutions
+	}
+	if s.Consistency != nil {
+		r.Consistency = *s.Consistency
+	}
+	r.WPMSamples = s.WPMSamples
+	if s.Sentence != nil && s.Keys != nil && len(s.Keys.Keys) > 0 {
+		keystrokes, err := encodeKeystrokes(*s.Keys)
+		if err != nil {
+			log.Printf("Dropping keystrokes of player %d's score: %v", playerID, err)
+		} else {
+			r.Sentence = *s.Sentence
+			r.Keystrokes = keystrokes
+		}
+	}
+	return r
+}
+
 var scoreMu sync.Mutex
 
-func SaveScore(playerID int, score Score) error {
+// SaveScore stores the score with its keystrokes and sets its ID.
+func SaveScore(playerID int, score *Score) error {
 	scoreMu.Lock()
 	defer scoreMu.Unlock()
 
-	createdAt := time.Now()
+	record := score.record(playerID)
+	record.CreatedAt = time.Now().UTC()
 	if score.CreatedAt != nil && !score.CreatedAt.IsZero() {
-		createdAt = *score.CreatedAt
+		record.CreatedAt = score.CreatedAt.UTC()
 	}
 
-	tx, err := data.DB.Begin()
+	id, err := data.Repo.Scores().Add(record)
 	if err != nil {
 		return err
 	}
-
-	_, err = tx.Exec(`
-        INSERT INTO scores (player_id, accuracy, wpm, tp, duration, created_at)
-        VALUES (?, ?, ?, ?, ?, ?)
-    `, playerID, score.Accuracy, score.WPM, score.TP, score.Duration, createdAt)
-	if err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	if err := tx.Commit(); err != nil {
-		return err
+	score.ID = &id
+	score.PlayerID = &playerID
+
+	if score.Sentence != nil && score.Keys != nil {
+		a := NewAnalytics()
+		a.Add(*score.Sentence, *score.Keys)
+		// The score itself is saved, so only log if the key stats aren't
+		if err := saveAnalytics(playerID, a); err != nil {
+			log.Println("DB error saving key stats:", err)
+		}
 	}
 
 	log.Printf("Player with id %d submitted a score", playerID)
@@ -0,0 +1,120 @@
+package player
+
+import (
+	"testing"
+	"time"
+
+	"ssh-battle/util"
+)
+
+func TestScoreCalculation(t *testing.T) {
+	const ref = "hello world"
+	// Six seconds is a tenth of a minute, so a character is 2 raw WPM and an
+	// uncorrected error costs 10 net WPM
+	const elapsed = 6 * time.Second
+
+	tests := []struct {
+		name        string
+		typed       string
+		raw, net    float64
+		corrected   int
+		uncorrected int
+		edits       EditCounts
+	}{
+		{"perfect", "hello world", 22, 22, 0, 0, EditCounts{}},
+		{"typo fixed", "ha\bello world", 24, 24, 1, 0, EditCounts{}},
+		{"right character deleted with a typo", "helo\b\bllo world", 26, 26, 1, 0, EditCounts{}},
+		{"typo left in", "hallo world", 22, 12, 0, 1, EditCounts{Substitutions: 1}},
+		{"missed letter", "helo world", 20, 10, 0, 1, EditCounts{Deletions: 1}},
+		{"extra letter", "helllo world", 24, 14, 0, 1, EditCounts{Insertions: 1}},
+		{"fixed one, left one", "hx\bello wprld", 24, 14, 1, 1, EditCounts{Substitutions: 1}},
+		// The untyped rest is costed by accuracy, not as errors
+		{"stopped early", "hello", 10, 10, 0, 0, EditCounts{}},
+		{"net WPM doesn't go below zero", "xxxxx xxxxx", 22, 0, 0, 10, EditCounts{Substitutions: 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := ScoreCalculation(ref, typeEvenly(tt.typed, elapsed), util.Easy)
+			if !near(*s.RawWPM, tt.raw) || !near(*s.WPM, tt.net) {
+				t.Errorf("raw/net WPM = %v/%v, want %v/%v", *s.RawWPM, *s.WPM, tt.raw, tt.net)
+			}
+			if *s.CorrectedErrors != tt.corrected || *s.UncorrectedErrors != tt.uncorrected {
+				t.Errorf("corrected/uncorrected errors = %d/%d, want %d/%d", *s.CorrectedErrors, *s.UncorrectedErrors, tt.corrected, tt.uncorrected)
+			}
+			got := EditCounts{Insertions: *s.Insertions, Deletions: *s.Deletions, Substitutions: *s.Substitutions}
+			if got != tt.edits {
+				t.Errorf("edits = %+v, want %+v", got, tt.edits)
+			}
+			if *s.Duration != 6 {
+				t.Errorf("duration = %d, want 6", *s.Duration)
+			}
+		})
+	}
+}
+
+func TestScoreCalculationConsistency(t *testing.T) {
+	ref := "aaaaaaaaaaaaaaaaaaaa"
+
+	// Five characters every second
+	var steady KeystrokeLog
+	for i := range 20 {
+		steady.Keys = append(steady.Keys, Keystroke{At: time.Duration(i) * 200 * time.Millisecond, Rune: 'a'})
+	}
+	steady.Elapsed = 4 * time.Second
+
+	s := ScoreCalculation(ref, steady, util.Easy)
+	if len(s.WPMSamples) != 4 || !near(*s.Consistency, 100) {
+		t.Errorf("steady run: samples %v, consistency %v, want 4 samples at 100", s.WPMSamples, *s.Consistency)
+	}
+	if !near(*s.RawWPM, 60) {
+		t.Errorf("steady run: raw WPM %v, want 60", *s.RawWPM)
+	}
+
+	// The same characters, all typed in the first two seconds
+	var bursty KeystrokeLog
+	for i := range 20 {
+		bursty.Keys = append(bursty.Keys, Keystroke{At: time.Duration(i) * 100 * time.Millisecond, Rune: 'a'})
+	}
+	bursty.Elapsed = 4 * time.Second
+
+	// Samples 120, 120, 0, 0: mean 60, standard deviation 60
+	b := ScoreCalculation(ref, bursty, util.Easy)
+	if !near(*b.Consistency, 0) {
+		t.Errorf("bursty run: consistency %v, want 0", *b.Consistency)
+	}
+	if !near(*b.RawWPM, *s.RawWPM) {
+		t.Errorf("bursty run: raw WPM %v, want the steady run's %v", *b.RawWPM, *s.RawWPM)
+	}
+}
+
+func TestScoreCalculationComposesRef(t *testing.T) {
+	// "é" as e and a combining acute accent, the terminal sends it composed
+	s := ScoreCalculation("cafe\u0301", typeEvenly("caf\u00e9", 6*time.Second), util.Easy)
+	if *s.UncorrectedErrors != 0 || *s.Accuracy != 100 {
+		t.Errorf("accuracy %v with %d errors, want a perfect run", *s.Accuracy, *s.UncorrectedErrors)
+	}
+}
+
+func TestScoreCalculationSkipsIndentation(t *testing.T) {
+	ref := "if ok {\n    return\n}"
+	var keys KeystrokeLog
+	indenting := false
+	for i, r := range []rune(ref) {
+		// The indentation after a line break is entered for the player
+		indenting = r == '\n' || indenting && r == ' '
+		keys.Keys = append(keys.Keys, Keystroke{At: time.Duration(i) * 100 * time.Millisecond, Rune: r, Auto: indenting && r == ' '})
+	}
+	keys.Elapsed = 6 * time.Second
+
+	s := ScoreCalculation(ref, keys, util.Easy)
+	// 20 characters, 4 of them indentation
+	if !near(*s.RawWPM, 32) || *s.UncorrectedErrors != 0 {
+		t.Errorf("raw WPM %v with %d errors, want 32 with none", *s.RawWPM, *s.UncorrectedErrors)
+	}
+
+	a := NewAnalytics()
+	a.Add(ref, keys)
+	if presses := a.Keys[' '].Presses; presses != 2 {
+		t.Errorf("space pressed %d times, want only the 2 on the first line", presses)
+	}
+}
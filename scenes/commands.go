@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sort"
 	"ssh-battle/player"
+	"strings"
 
 	glider "github.com/gliderlabs/ssh"
 	"golang.org/x/term"
@@ -14,7 +15,11 @@ type Command struct {
 	Description string
 	Handler     func(shell *term.Terminal)
 	NextScene   Scene // nil if no scene transition
-	Quit        bool
+	// ArgsScene builds the next scene when the command is given arguments,
+	// e.g. ":leaderboard week". Commands without it take none.
+	ArgsScene func(args []string) (Scene, error)
+	Usage     string // shown in help for commands with arguments
+	Quit      bool
 }
 
 var commandRegistry map[string]Command
@@ -43,6 +48,25 @@ func init() {
 			Description: "start single player game",
 			Handler:     func(_ *term.Terminal) {},
 			NextScene:   Game,
+			ArgsScene:   gameArgs,
+			Usage:       ":game [sentence|15s|30s|60s|120s|10w|25w|50w|100w] [words|quotes|code] [easy|normal|hard] [en|nl|de|...] [seed:<n>]",
+		},
+		":daily": {
+			Description: "play today's daily challenge and see its board",
+			Handler:     func(_ *term.Terminal) {},
+			NextScene:   DailyChallenge,
+		},
+		":ghost": {
+			Description: "race your personal best, or any run by number",
+			Handler:     func(_ *term.Terminal) {},
+			NextScene:   GhostRace,
+			ArgsScene:   ghostArgs,
+			Usage:       ":ghost [run #]",
+		},
+		":practice": {
+			Description: "practice sentences built around your weak keys",
+			Handler:     func(_ *term.Terminal) {},
+			NextScene:   FocusedPractice,
 		},
 		":lobby": {
 			Description: "go to multiplayer lobby",
@@ -54,16 +78,48 @@ func init() {
 			Handler:     func(_ *term.Terminal) {},
 			NextScene:   ScoreList,
 		},
+		":stats": {
+			Description: "view your weak keys and slowest letter pairs",
+			Handler:     func(_ *term.Terminal) {},
+			NextScene:   WeakKeys,
+		},
 		":leaderboard": {
 			Description: "view global leaderboard",
 			Handler:     func(_ *term.Terminal) {},
 			NextScene:   Leaderboard,
+			ArgsScene:   leaderboardArgs,
+			Usage:       ":leaderboard [day|week|month|all] [best|avg|runs] [sentence|30s|25w|...] [words|quotes|code] [en|nl|de|...]",
 		},
 		":duos": {
 			Description: "join duos battle arena",
 			Handler:     func(_ *term.Terminal) {},
 			NextScene:   Duos,
 		},
+		":replay": {
+			Description: "watch a saved run again",
+			Handler: func(shell *term.Terminal) {
+				shell.Write([]byte("\033[38;5;248mUsage: \033[1;38;5;51m:replay <run #> [1x|2x]\033[0m\n"))
+			},
+			ArgsScene: replayArgs,
+			Usage:     ":replay <run #> [1x|2x]",
+		},
+		":keys": {
+			Description: "manage your SSH login keys",
+			Handler:     func(_ *term.Terminal) {},
+			NextScene:   Keys,
+		},
+		":language": {
+			Description: "pick the language your words are in",
+			Handler:     func(_ *term.Terminal) {},
+			NextScene:   Language,
+			ArgsScene:   languageArgs,
+			Usage:       ":language [en|nl|de|...]",
+		},
+		":reload": {
+			Description: "reload the word lists (admins only)",
+			Handler:     func(_ *term.Terminal) {},
+			NextScene:   Reload,
+		},
 	}
 
 	AddAlias(":exit", ":q")
@@ -76,6 +132,10 @@ func init() {
 	AddAlias(":top", ":leaderboard")
 	AddAlias(":history", ":scores")
 	AddAlias(":battle", ":duos")
+	AddAlias(":ssh", ":keys")
+	AddAlias(":watch", ":replay")
+	AddAlias(":weak", ":stats")
+	AddAlias(":lang", ":language")
 }
 
 // Enhanced help command with better formatting
@@ -113,15 +173,20 @@ func helpHandler(shell *term.Terminal) {
 			}
 		}
 		if len(aliases_for_cmd) > 0 {
-			shell.Write(fmt.Appendf(nil, "\033[38;5;240m                Aliases: %s\033[0m\n", 
+			shell.Write(fmt.Appendf(nil, "\033[38;5;240m                Aliases: %s\033[0m\n",
 				fmt.Sprintf("%v", aliases_for_cmd)))
 		}
+		if data.Usage != "" {
+			shell.Write(fmt.Appendf(nil, "\033[38;5;240m                Usage: %s\033[0m\n", data.Usage))
+		}
 	}
 
 	shell.Write([]byte("\n\033[38;5;229mExamples:\033[0m\n"))
 	shell.Write([]byte("\033[38;5;252m─────────\033[0m\n"))
 	shell.Write([]byte("\033[38;5;248m• Type \033[1;38;5;51m:game\033[0m\033[38;5;248m to start single player\033[0m\n"))
 	shell.Write([]byte("\033[38;5;248m• Type \033[1;38;5;51m:duos\033[0m\033[38;5;248m to join battle arena\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Type \033[1;38;5;51m:leaderboard week\033[0m\033[38;5;248m to see this week's best\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Type \033[1;38;5;51m:replay 42 2x\033[0m\033[38;5;248m to watch run #42 at double speed\033[0m\n"))
 	shell.Write([]byte("\033[38;5;248m• Type \033[1;38;5;51m:main\033[0m\033[38;5;248m for quick main menu access\033[0m\n\n"))
 }
 
@@ -140,6 +205,29 @@ func SafeReadInput(shell *term.Terminal, s glider.Session, p *player.Player) (st
 			return "", nil, true
 		}
 
+		// Split off arguments, ":leaderboard week" -> ":leaderboard", ["week"]
+		name, args := input, []string(nil)
+		if fields := strings.Fields(input); len(fields) > 1 && strings.HasPrefix(fields[0], ":") {
+			name, args = fields[0], fields[1:]
+		}
+
+		if cmd, ok := commandRegistry[name]; ok && len(args) > 0 {
+			if cmd.ArgsScene == nil {
+				shell.Write([]byte("\033[38;5;196m❌ " + name + " doesn't take arguments\033[0m\n"))
+				continue
+			}
+			nextScene, err := cmd.ArgsScene(args)
+			if err != nil {
+				shell.Write([]byte("\033[38;5;196m❌ " + err.Error() + "\033[0m\n"))
+				if cmd.Usage != "" {
+					shell.Write([]byte("\033[38;5;248mUsage: \033[1;38;5;51m" + cmd.Usage + "\033[0m\n"))
+				}
+				continue
+			}
+			shell.Write([]byte("\033[2J\033[H"))
+			return "", nextScene, true
+		}
+
 		if cmd, ok := commandRegistry[input]; ok {
 			cmd.Handler(shell)
 			if cmd.Quit {
@@ -167,12 +255,12 @@ func SafeReadInput(shell *term.Terminal, s glider.Session, p *player.Player) (st
 func ShowControlHints(shell *term.Terminal, customHints ...string) {
 	shell.Write([]byte("\033[38;5;229mControls:\033[0m\n"))
 	shell.Write([]byte("\033[38;5;252m─────────\033[0m\n"))
-	
+
 	// Show custom hints first
 	for _, hint := range customHints {
 		shell.Write([]byte("\033[38;5;248m• " + hint + "\033[0m\n"))
 	}
-	
+
 	// Always show these universal controls
 	shell.Write([]byte("\033[38;5;248m• Type \033[1;38;5;51m:main\033[0m\033[38;5;248m to return to main menu\033[0m\n"))
 	shell.Write([]byte("\033[38;5;248m• Type \033[1;38;5;51m:help\033[0m\033[38;5;248m for all commands\033[0m\n"))
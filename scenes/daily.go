@@ -0,0 +1,208 @@
+package scenes
+
+import (
+	"fmt"
+	"log"
+	"ssh-battle/player"
+	"ssh-battle/stats"
+	"ssh-battle/util"
+	"time"
+
+	glider "github.com/gliderlabs/ssh"
+	"golang.org/x/term"
+)
+
+// dailyBoardSize is how many of today's attempts the board shows, the
+// player's own row is added below when it's further down.
+const dailyBoardSize = 10
+
+// DailyChallenge shows today's board and starts the challenge. Everyone types
+// the same text each UTC day; the first attempt is ranked, retries are
+// practice.
+func DailyChallenge(s glider.Session, p *player.Player) Scene {
+	shell := p.Shell
+	clearTerminal(shell)
+	day := player.DailyDay(time.Now())
+
+	shell.Write([]byte("\033[38;5;45m┌────────────────────────────────────────────────┐\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m│ 📅 \033[1;38;5;51mDaily Challenge\033[0m\033[38;5;45m                           │\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m└────────────────────────────────────────────────┘\033[0m\n\n"))
+
+	shell.Write([]byte("\033[38;5;229mInstructions:\033[0m\n"))
+	shell.Write([]byte("\033[38;5;252m──────────────\033[0m\n"))
+	shell.Write(fmt.Appendf(nil, "\033[38;5;248m• Everyone types the same %d words today (%s, %s)\033[0m\n",
+		player.DailyWords, player.DailyOptions.Difficulty, util.LanguageName(player.DailyOptions.Language)))
+	shell.Write([]byte("\033[38;5;248m• Your first attempt is ranked, it counts as soon as the text is shown\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Retry as often as you like, retries are practice\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Finish a ranked attempt every day to keep your streak going\033[0m\n\n"))
+
+	left := time.Until(time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour))
+	shell.Write(fmt.Appendf(nil, "\033[38;5;229mToday:\033[0m \033[1;38;5;51m%s\033[0m\033[38;5;248m · next challenge in %dh %02dm\033[0m\n",
+		day, int(left.Hours()), int(left.Minutes())%60))
+
+	used, err := p.DailyUsed(day)
+	if err != nil {
+		log.Println("DB error checking daily attempt:", err)
+	}
+	switch {
+	case p.Guest:
+		shell.Write([]byte("\033[38;5;208mGuests play for practice, create an account to get on the board.\033[0m\n\n"))
+	case used:
+		shell.Write([]byte("\033[38;5;248mYou've used today's ranked attempt, retries are practice.\033[0m\n"))
+	default:
+		shell.Write([]byte("\033[1;38;5;46mYour ranked attempt is ready.\033[0m\n"))
+	}
+	if !p.Guest {
+		streak, err := stats.Default().Streak(p.ID, day)
+		if err != nil {
+			log.Println("DB error loading streak:", err)
+		}
+		shell.Write(fmt.Appendf(nil, "\033[38;5;248mStreak: \033[1;38;5;214m🔥 %s\033[0m\033[38;5;248m (best %s)\033[0m\n\n", days(streak.Current), days(streak.Best)))
+	}
+
+	renderDailyBoard(shell, p, day)
+
+	shell.Write([]byte("\033[38;5;46mPress Enter to start, or :main to go back...\033[0m\n"))
+	shell.Write([]byte("\033[38;5;208m> \033[0m"))
+	_, nextScene, done := SafeReadInput(shell, s, p)
+	if done {
+		return nextScene
+	}
+	return playDaily(s, p)
+}
+
+// playDaily runs one attempt at today's challenge.
+func playDaily(s glider.Session, p *player.Player) Scene {
+	shell := p.Shell
+	// The day the text is shown decides the board, even if it's finished
+	// after midnight
+	day := player.DailyDay(time.Now())
+	seed := util.DailySeed(day)
+
+	text, err := player.DailyText(day)
+	if err != nil {
+		return noText(s, p, err)
+	}
+	widget := newTypingWidget(shell, text, 0)
+	widget.lines, widget.width = 3, termWidth(p)
+	ranked, err := p.StartDaily(day)
+	if err != nil {
+		log.Println("DB error starting daily attempt:", err)
+	}
+
+	shell.Write([]byte("\n"))
+	if ranked {
+		shell.Write([]byte("\033[1;38;5;46m🏅 Ranked attempt, good luck!\033[0m\n"))
+	} else {
+		shell.Write([]byte("\033[38;5;248m🎯 Practice attempt\033[0m\n"))
+	}
+	keys, nextScene, done := widget.Run(s, p)
+	if done {
+		return nextScene
+	}
+
+	score := player.ScoreCalculation(widget.Target(), keys, player.DailyOptions.Difficulty)
+	score.Mode = player.ModeDaily
+	score.Language = player.DailyOptions.Language
+	score.Seed = seed
+	score.Practice = !ranked
+	if !p.Guest {
+		if err := player.SaveScore(p.ID, &score); err != nil {
+			log.Println("DB error saving score:", err)
+		} else if ranked {
+			if err := p.FinishDaily(day, *score.ID); err != nil {
+				log.Println("DB error finishing daily attempt:", err)
+			}
+		}
+	}
+	p.Scores = append(p.Scores, score)
+
+	writeResults(shell, score, termWidth(p))
+	if ranked {
+		shell.Write([]byte("\033[38;5;46m🏅 Your ranked attempt is on today's board.\033[0m\n\n"))
+	} else {
+		shell.Write([]byte("\033[38;5;248m🎯 Practice run, it won't show on the board.\033[0m\n\n"))
+	}
+
+	shell.Write([]byte("\033[38;5;46mPress Enter to see today's board...\033[0m\n"))
+	shell.Write([]byte("\033[38;5;208m> \033[0m"))
+	_, nextScene, done = SafeReadInput(shell, s, p)
+	if done {
+		return nextScene
+	}
+	return DailyChallenge
+}
+
+// renderDailyBoard prints the top of day's board with each player's streak.
+func renderDailyBoard(shell *term.Terminal, p *player.Player, day string) {
+	shell.Write([]byte("\033[38;5;229mToday's Board:\033[0m\n"))
+	shell.Write([]byte("\033[38;5;252m───────────────\033[0m\n"))
+
+	board := stats.Default()
+	entries, err := board.Daily(day)
+	if err != nil {
+		shell.Write([]byte("Can't find info\n"))
+		log.Print(err)
+		return
+	}
+	if len(entries) == 0 {
+		shell.Write([]byte("\033[38;5;248mNobody has finished today's challenge yet. Be the first!\033[0m\n\n"))
+		return
+	}
+
+	shown := entries[:min(len(entries), dailyBoardSize)]
+	for _, e := range entries[len(shown):] {
+		if !p.Guest && e.PlayerID == p.ID {
+			shown = append(shown[:len(shown):len(shown)], e)
+		}
+	}
+
+	ids := make([]int, len(shown))
+	for i, e := range shown {
+		ids[i] = e.PlayerID
+	}
+	streaks, err := board.Streaks(ids, day)
+	if err != nil {
+		log.Println("DB error loading streaks:", err)
+	}
+
+	shell.Write([]byte("\033[38;5;45m┌────────┬─────────────┬──────────┬───────┬──────────┬───────────┐\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m│ Rank   │ Player      │ Accuracy │ WPM   │ Streak   │ TP Score  │\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m├────────┼─────────────┼──────────┼───────┼──────────┼───────────┤\033[0m\n"))
+	for _, e := range shown {
+		rankColor := "\033[38;5;252m"
+		switch e.Rank {
+		case 1:
+			rankColor = "\033[38;5;226m"
+		case 2:
+			rankColor = "\033[38;5;250m"
+		case 3:
+			rankColor = "\033[38;5;172m"
+		}
+		if !p.Guest && e.PlayerID == p.ID {
+			rankColor = "\033[1;38;5;46m"
+		}
+
+		playerName := e.PlayerName
+		if len(playerName) > 11 {
+			playerName = playerName[:11]
+		}
+
+		shell.Write(fmt.Appendf(nil, "%s│ %-6d │ %-11s │ %7.2f%% │ %5.1f │ %-8s │ %9.2f │\033[0m\n",
+			rankColor, e.Rank, playerName, e.Accuracy, e.WPM, days(streaks[e.PlayerID].Current), e.TP))
+	}
+	shell.Write([]byte("\033[38;5;45m└────────┴─────────────┴──────────┴───────┴──────────┴───────────┘\033[0m\n"))
+	if len(entries) == 1 {
+		shell.Write([]byte("\033[38;5;248m1 player finished today's challenge\033[0m\n\n"))
+	} else {
+		shell.Write(fmt.Appendf(nil, "\033[38;5;248m%d players finished today's challenge\033[0m\n\n", len(entries)))
+	}
+}
+
+// days writes a streak length like "1 day" or "12 days".
+func days(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
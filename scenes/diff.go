@@ -0,0 +1,118 @@
+package scenes
+
+import (
+	"ssh-battle/player"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// diffCell is one coloured column of a rendered diff.
+type diffCell struct {
+	r     rune
+	color string
+}
+
+const (
+	diffMatch   = "\033[38;5;46m"
+	diffWrong   = "\033[38;5;196m"
+	diffMissed  = "\033[38;5;214m"
+	diffPadding = "\033[38;5;240m"
+)
+
+// diffWordCells lays out one word of a diff as two rows of equal width, the
+// target above and what was typed below.
+func diffWordCells(w player.DiffWord) (target, typed []diffCell) {
+	pad := diffCell{'·', diffPadding}
+
+	switch w.Op {
+	case player.OpMatch:
+		for _, r := range w.Ref {
+			target = append(target, diffCell{r, diffMatch})
+			typed = append(typed, diffCell{r, diffMatch})
+		}
+	case player.OpInsert:
+		for _, r := range w.Typed {
+			target = append(target, pad)
+			typed = append(typed, diffCell{r, diffWrong})
+		}
+	case player.OpDelete:
+		for _, r := range w.Ref {
+			target = append(target, diffCell{r, diffMissed})
+			typed = append(typed, pad)
+		}
+	case player.OpSubstitute:
+		for _, c := range w.Chars {
+			switch c.Op {
+			case player.OpMatch:
+				target = append(target, diffCell{c.Ref, diffMatch})
+				typed = append(typed, diffCell{c.Typed, diffMatch})
+			case player.OpSubstitute:
+				target = append(target, diffCell{c.Ref, diffMissed})
+				typed = append(typed, diffCell{c.Typed, diffWrong})
+			case player.OpInsert:
+				target = append(target, pad)
+				typed = append(typed, diffCell{c.Typed, diffWrong})
+			case player.OpDelete:
+				target = append(target, diffCell{c.Ref, diffMissed})
+				typed = append(typed, pad)
+			}
+		}
+	}
+	return target, typed
+}
+
+// writeDiff prints the target and the attempt one above the other, wrapped
+// to width, so every mistake lines up with what it should have been.
+func writeDiff(shell *term.Terminal, diff player.Diff, width int) {
+	const label = 9 // "Target │ "
+	width = max(width-label, 20)
+
+	var target, typed []diffCell
+	flush := func() {
+		if len(target) == 0 {
+			return
+		}
+		shell.Write([]byte("\033[38;5;248mTarget │ \033[0m" + renderCells(target) + "\n"))
+		shell.Write([]byte("\033[38;5;248mTyped  │ \033[0m" + renderCells(typed) + "\n"))
+		target, typed = nil, nil
+	}
+
+	for _, w := range diff {
+		t, y := diffWordCells(w)
+		if len(target) > 0 && len(target)+1+len(t) > width {
+			flush()
+		}
+		if len(target) > 0 {
+			target = append(target, diffCell{' ', ""})
+			typed = append(typed, diffCell{' ', ""})
+		}
+		target = append(target, t...)
+		typed = append(typed, y...)
+	}
+	flush()
+
+	shell.Write([]byte("\033[38;5;240m" + diffMatch + "correct\033[0m · " + diffWrong + "typed wrong\033[0m · " + diffMissed + "expected\033[0m · " + diffPadding + "· nothing\033[0m\n\n"))
+}
+
+func renderCells(cells []diffCell) string {
+	var b strings.Builder
+	color := ""
+	for _, c := range cells {
+		if c.color != color {
+			b.WriteString("\033[0m" + c.color)
+			color = c.color
+		}
+		b.WriteRune(c.r)
+	}
+	b.WriteString("\033[0m")
+	return b.String()
+}
+
+// termWidth is the player's terminal width, 80 if the client didn't say.
+func termWidth(p *player.Player) int {
+	if p.PtyReq != nil && p.PtyReq.Window.Width > 0 {
+		return p.PtyReq.Window.Width
+	}
+	return 80
+}
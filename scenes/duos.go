@@ -8,22 +8,15 @@ import (
 	"ssh-battle/util"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	glider "github.com/gliderlabs/ssh"
 )
 
-// Singleton duos behavior to ensure all players share the same game state
-var duosBehaviorInstance *DuosRoomBehavior
-var duosBehaviorOnce sync.Once
-
-func getDuosBehavior() *DuosRoomBehavior {
-	duosBehaviorOnce.Do(func() {
-		duosBehaviorInstance = &DuosRoomBehavior{
-			gameTimeLimit: 60 * time.Second, // 60 second time limit
-		}
-	})
-	return duosBehaviorInstance
+// newDuosBehavior is the state of one battle, every matched pair gets its own.
+func newDuosBehavior() *DuosRoomBehavior {
+	return &DuosRoomBehavior{gameTimeLimit: gameConfig.DuosTimeLimit}
 }
 
 func Duos(s glider.Session, p *player.Player) Scene {
@@ -37,19 +30,24 @@ func Duos(s glider.Session, p *player.Player) Scene {
 
 	shell.Write([]byte("\033[38;5;229mControls:\033[0m\n"))
 	shell.Write([]byte("\033[38;5;252m─────────\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• You're paired with the next player looking for a battle, press Esc to stop looking\033[0m\n"))
 	shell.Write([]byte("\033[38;5;248m• Type 'ready' to start the game\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Type 'words', 'quotes' or 'code' to pick what the room types\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Type 'easy', 'normal' or 'hard' to set how the words are generated\033[0m\n"))
+	shell.Write(fmt.Appendf(nil, "\033[38;5;248m• Type a language (%s) to pick the words' language\033[0m\n", strings.Join(util.Languages(), ", ")))
 	shell.Write([]byte("\033[38;5;248m• Type :main to return to main menu\033[0m\n"))
 	shell.Write([]byte("\033[38;5;248m• Use :q to quit, :help for all commands\033[0m\n\n"))
 
 	shell.Write([]byte("\033[38;5;229mWaiting:\033[0m\n"))
 	shell.Write([]byte("\033[38;5;252m────────\033[0m\n"))
-	shell.Write([]byte("\033[38;5;248mWaiting for another player to join...\033[0m\n\n"))
+	shell.Write([]byte("\033[38;5;248mLooking for an opponent...\033[0m\n"))
 
-	// Get or create the duos room - use singleton behavior
-	room := GetRoom("Duos", getDuosBehavior())
-
-	// Join the room
-	room.Join <- p
+	// Every pair battles in a room of its own
+	room, nextScene, done := findOpponent(s, p)
+	if done {
+		return nextScene
+	}
+	duosBehavior := room.Behavior.(*DuosRoomBehavior)
 	defer func() {
 		room.Leave <- p
 		p.Ready = false // Reset ready state when leaving
@@ -58,6 +56,9 @@ func Duos(s glider.Session, p *player.Player) Scene {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Set while the player is typing, so messages don't break up the sentence
+	var typing atomic.Pointer[typingWidget]
+
 	// Listen for incoming messages with improved error handling
 	go func() {
 		defer func() {
@@ -74,6 +75,10 @@ func Duos(s glider.Session, p *player.Player) Scene {
 				if !ok {
 					return
 				}
+				if w := typing.Load(); w != nil {
+					w.Notify(msg)
+					continue
+				}
 				// Clear current line and print message, then restore prompt
 				shell.Write([]byte("\033[2K\r")) // Clear line
 				shell.Write([]byte("\033[38;5;252m" + msg + "\033[0m\n"))
@@ -94,11 +99,30 @@ func Duos(s glider.Session, p *player.Player) Scene {
 			}
 			return nil
 		}
+		if opponentLeft(room) {
+			shell.Write([]byte("\033[38;5;248m🔄 Your opponent left, looking for a new one...\033[0m\n"))
+			return Duos
+		}
 
 		if input == "ready" {
 			p.Ready = true
 			room.Broadcast <- RoomMessage{"Server", fmt.Sprintf("\033[38;5;46m⚡ %s is ready to battle!\033[0m", p.Name)}
 			break
+		} else if source, ok := util.ParseSource(input); ok {
+			duosBehavior.SetSource(source)
+			msg := fmt.Sprintf("\033[38;5;51m📜 %s picked %s for this battle\033[0m", p.Name, source.Name())
+			room.Broadcast <- RoomMessage{"Server", msg}
+			shell.Write([]byte(msg + "\n"))
+		} else if difficulty, ok := util.ParseDifficulty(input); ok && input != "" {
+			duosBehavior.SetDifficulty(difficulty)
+			msg := fmt.Sprintf("\033[38;5;51m🎚️ %s set this battle to %s\033[0m", p.Name, difficulty)
+			room.Broadcast <- RoomMessage{"Server", msg}
+			shell.Write([]byte(msg + "\n"))
+		} else if language, ok := util.ParseLanguage(input); ok {
+			duosBehavior.SetLanguage(language)
+			msg := fmt.Sprintf("\033[38;5;51m🌍 %s picked %s for this battle\033[0m", p.Name, util.LanguageName(language))
+			room.Broadcast <- RoomMessage{"Server", msg}
+			shell.Write([]byte(msg + "\n"))
 		} else if input != "" {
 			shell.Write([]byte("\033[38;5;196m❌ Type 'ready' to start the game or ESC for main menu.\033[0m\n"))
 		}
@@ -131,6 +155,15 @@ func Duos(s glider.Session, p *player.Player) Scene {
 		if playerCount >= 2 && readyCount == playerCount {
 			break
 		}
+		if opponentLeft(room) {
+			shell.Write([]byte("\033[38;5;248m🔄 Your opponent left, looking for a new one...\033[0m\n"))
+			return Duos
+		}
+
+		if shuttingDown.Load() {
+			shell.Write([]byte("\033[38;5;196m🔧 Server is restarting, no new battles can start.\033[0m\n"))
+			return Main
+		}
 
 		time.Sleep(500 * time.Millisecond)
 
@@ -143,21 +176,30 @@ func Duos(s glider.Session, p *player.Player) Scene {
 	}
 
 	// Only try to start the game once - let the room behavior handle it
-	getDuosBehavior().TryStartGame(room)
+	if err := duosBehavior.TryStartGame(room); err != nil {
+		room.Broadcast <- RoomMessage{"Server", "\033[38;5;196m❌ The battle couldn't start, nothing to type right now.\033[0m"}
+		return noText(s, p, err)
+	}
 
 	// Wait for game to actually start and get the sentence
 	shell.Write([]byte("\033[38;5;248m🎮 Preparing battle arena...\033[0m\n"))
 	var sentence string
-	duosBehavior := getDuosBehavior()
+	var passage util.Passage
+	var source string
 	for {
 		duosBehavior.mu.Lock()
 		started := duosBehavior.gameStarted
-		sentence = duosBehavior.sentence
+		passage, source = duosBehavior.passage, duosBehavior.passageSource
+		sentence = passage.Text
 		duosBehavior.mu.Unlock()
 
 		if started && sentence != "" {
 			break
 		}
+		if shuttingDown.Load() {
+			shell.Write([]byte("\033[38;5;196m🔧 Server is restarting, no new battles can start.\033[0m\n"))
+			return Main
+		}
 		time.Sleep(100 * time.Millisecond)
 	}
 
@@ -166,7 +208,7 @@ func Duos(s glider.Session, p *player.Player) Scene {
 	shell.Write([]byte("\033[38;5;45m┌────────────────────────────────────────────────┐\033[0m\n"))
 	shell.Write([]byte("\033[38;5;45m│ ⚔️ \033[1;38;5;51mBATTLE STARTING\033[0m\033[38;5;45m                             │\033[0m\n"))
 	shell.Write([]byte("\033[38;5;45m└────────────────────────────────────────────────┘\033[0m\n\n"))
-	
+
 	for i := 3; i > 0; i-- {
 		shell.Write([]byte("\033[2K\r")) // Clear line
 		shell.Write(fmt.Appendf(nil, "\033[38;5;46m🚀 Starting in %d...\033[0m", i))
@@ -178,54 +220,35 @@ func Duos(s glider.Session, p *player.Player) Scene {
 	// Display the sentence with better formatting and time limit
 	shell.Write([]byte("\033[38;5;229m📝 Type this sentence:\033[0m\n"))
 	shell.Write([]byte("\033[38;5;252m" + strings.Repeat("─", 50) + "\033[0m\n"))
-	shell.Write(fmt.Appendf(nil, "\033[1;38;5;252m%s\033[0m\n", sentence))
-	shell.Write([]byte("\033[38;5;252m" + strings.Repeat("─", 50) + "\033[0m\n"))
-	shell.Write([]byte("\033[38;5;196m⏰ Time limit: 60 seconds\033[0m\n\n"))
-	shell.Write([]byte("\033[38;5;229m⌨️  Your typing:\033[0m\n"))
-	shell.Write([]byte("\033[38;5;208m> \033[0m"))
+	writeAttribution(shell, passage)
 	log.Printf("Player %s got sentence: %s", p.Name, sentence)
 
-	// Record start time and get input with time limit
-	start := time.Now()
-	timeLimit := 60 * time.Second
-	
-	// Create a channel to receive input or timeout
-	inputChan := make(chan string, 1)
-	errorChan := make(chan error, 1)
-	
-	// Start goroutine to read input
-	go func() {
-		input, _, finished := SafeReadInput(shell, s, p)
-		if finished {
-			errorChan <- fmt.Errorf("player disconnected")
-			return
-		}
-		inputChan <- input
-	}()
-	
-	var input string
-	var timedOut bool
-	
-	// Wait for input or timeout
-	select {
-	case input = <-inputChan:
-		// Got input
-	case <-errorChan:
-		// Player disconnected during game - they forfeit
+	// Read the attempt key by key with the time limit
+	timeLimit := duosBehavior.gameTimeLimit
+	widget := newTypingWidget(shell, sentence, timeLimit)
+	typing.Store(widget)
+	duosBehavior.mu.Lock()
+	duosBehavior.widgets[p.Name] = widget
+	duosBehavior.mu.Unlock()
+	keys, nextScene, finished := widget.Run(s, p)
+	typing.Store(nil)
+	if finished {
+		// Player left or disconnected during the game - they forfeit
 		cancel()
-		return nil
-	case <-time.After(timeLimit):
-		// Time limit exceeded
-		timedOut = true
-		input = "" // Empty input for timeout
-		shell.Write([]byte("\033[2K\r")) // Clear current line
-		shell.Write([]byte("\033[1;38;5;196m⏰ TIME'S UP! ⏰\033[0m\n"))
+		return nextScene
+	}
+
+	timedOut := widget.TimedOut()
+	input := keys.Text()
+	if timedOut {
+		shell.Write([]byte("\n\033[1;38;5;196m⏰ TIME'S UP! ⏰\033[0m\n"))
 	}
-	
-	elapsed := min(time.Since(start), timeLimit)
 
 	// Calculate and save score
-	score := player.ScoreCalculation(sentence, input, elapsed)
+	score := player.ScoreCalculation(sentence, keys, passage.Difficulty)
+	score.Source = source
+	score.Language = passage.Language
+	score.Seed = passage.Seed
 	if timedOut {
 		// Adjust score for timeout - set accuracy to 0 and low TP score
 		zeroAccuracy := 0.0
@@ -233,19 +256,33 @@ func Duos(s glider.Session, p *player.Player) Scene {
 		score.Accuracy = &zeroAccuracy
 		score.TP = &lowTP
 	}
-	p.Scores = append(p.Scores, score)
-	player.SaveScore(p.ID, score)
 
-	// Mark this player as finished and store their score
+	// Mark this player as finished and store their score, unless a shutdown
+	// already saved a partial score for them. Saving under the lock means the
+	// score has its ID before anyone reads the results.
+	var saveErr error
 	duosBehavior.mu.Lock()
-	duosBehavior.playerResults[p.Name] = PlayerResult{
-		Player: p,
-		Score:  &score,
-		Input:  input,
-		TimedOut: timedOut,
+	_, saved := duosBehavior.playerResults[p.Name]
+	if !saved {
+		if !p.Guest {
+			saveErr = player.SaveScore(p.ID, &score)
+		}
+		duosBehavior.playerResults[p.Name] = PlayerResult{
+			Player:   p,
+			Score:    &score,
+			Input:    input,
+			TimedOut: timedOut,
+		}
 	}
 	duosBehavior.mu.Unlock()
 
+	if !saved {
+		p.Scores = append(p.Scores, score)
+	}
+	if saveErr != nil {
+		log.Printf("DB error saving duos score for %s: %v", p.Name, saveErr)
+	}
+
 	// Only send completion message to OTHER players, not yourself
 	if timedOut {
 		// Don't broadcast timeout to self
@@ -282,45 +319,48 @@ func Duos(s glider.Session, p *player.Player) Scene {
 	shell.Write([]byte("\033[38;5;45m┌────────────────────────────────────────────────┐\033[0m\n"))
 	shell.Write([]byte("\033[38;5;45m│ ⏳ \033[1;38;5;51mWAITING FOR OTHER PLAYER\033[0m\033[38;5;45m               │\033[0m\n"))
 	shell.Write([]byte("\033[38;5;45m└────────────────────────────────────────────────┘\033[0m\n\n"))
-	
+
 	if timedOut {
 		shell.Write([]byte("\033[38;5;196m⏰ You ran out of time!\033[0m\n\n"))
 	} else {
 		shell.Write([]byte("\033[38;5;46m✅ You finished typing!\033[0m\n\n"))
 	}
+	if saveErr != nil {
+		shell.Write([]byte("\033[38;5;208m⚠ Your score couldn't be saved, it only counts for this battle.\033[0m\n\n"))
+	}
 	shell.Write([]byte("\033[38;5;248m🔒 Please wait for the other player to finish...\033[0m\n"))
 	shell.Write([]byte("\033[38;5;248m💡 You cannot exit until both players are done.\033[0m\n\n"))
 
 	// Wait for both players to finish (or timeout)
 	maxWaitTime := timeLimit + (10 * time.Second) // Extra time for the other player
 	waitStart := time.Now()
-	
+
 	for {
 		duosBehavior.mu.Lock()
 		resultCount := len(duosBehavior.playerResults)
 		allFinished := resultCount >= 2
 		duosBehavior.mu.Unlock()
-		
+
 		if allFinished {
 			break
 		}
-		
+
 		// Check if we've waited too long (other player disconnected/timed out)
 		if time.Since(waitStart) > maxWaitTime {
 			shell.Write([]byte("\033[38;5;196m⚠️  Other player appears to have disconnected. Proceeding to results...\033[0m\n"))
 			break
 		}
-		
+
 		// Show periodic updates
 		elapsed := time.Since(waitStart)
-		if int(elapsed.Seconds()) % 5 == 0 {
+		if int(elapsed.Seconds())%5 == 0 {
 			remaining := maxWaitTime - elapsed
 			if remaining > 0 {
 				shell.Write([]byte("\033[2K\r")) // Clear line
 				shell.Write(fmt.Appendf(nil, "\033[38;5;248m⏳ Still waiting... (timeout in %.0f seconds)\033[0m\n", remaining.Seconds()))
 			}
 		}
-		
+
 		time.Sleep(1 * time.Second)
 	}
 
@@ -339,7 +379,7 @@ func Duos(s glider.Session, p *player.Player) Scene {
 	duosBehavior.mu.Unlock()
 
 	// Sort results by TP score (descending)
-	for i := range len(results)-1 {
+	for i := range len(results) - 1 {
 		for j := i + 1; j < len(results); j++ {
 			if *results[i].Score.TP < *results[j].Score.TP {
 				results[i], results[j] = results[j], results[i]
@@ -362,15 +402,20 @@ func Duos(s glider.Session, p *player.Player) Scene {
 
 		shell.Write(fmt.Appendf(nil, "\033[38;5;229m%s Rank %d: %s\033[0m\n", rankIcon, rank, result.Player.Name))
 		shell.Write([]byte("\033[38;5;252m" + strings.Repeat("─", 30) + "\033[0m\n"))
-		
+
 		if result.TimedOut {
 			shell.Write([]byte("\033[38;5;196m⏰ TIMED OUT\033[0m\n"))
 		}
-		
+
 		shell.Write(fmt.Appendf(nil, "\033[38;5;248m🎯 Accuracy: \033[1;38;5;51m%.2f%%\033[0m\n", *result.Score.Accuracy))
 		shell.Write(fmt.Appendf(nil, "\033[38;5;248m⚡ WPM: \033[1;38;5;51m%.1f\033[0m\n", *result.Score.WPM))
 		shell.Write(fmt.Appendf(nil, "\033[38;5;248m⏱️  Time: \033[1;38;5;51m%d seconds\033[0m\n", *result.Score.Duration))
-		shell.Write(fmt.Appendf(nil, "\033[38;5;248m🏆 TP Score: \033[1;38;5;51m%.2f\033[0m\n\n", *result.Score.TP))
+		shell.Write(fmt.Appendf(nil, "\033[38;5;248m🏆 TP Score: \033[1;38;5;51m%.2f\033[0m\n", *result.Score.TP))
+		writeDiff(shell, player.Compare(sentence, result.Input), termWidth(p))
+		if result.Score.HasReplay() {
+			shell.Write(fmt.Appendf(nil, "\033[38;5;248m🎬 Replay: \033[1;38;5;51m:replay %d\033[0m\n", *result.Score.ID))
+		}
+		shell.Write([]byte("\n"))
 	}
 
 	// Send winner announcement only to other players  (Might change later)
@@ -378,12 +423,12 @@ func Duos(s glider.Session, p *player.Player) Scene {
 		winner := results[0]
 		var winMessage string
 		if *winner.Score.TP > *results[1].Score.TP {
-			winMessage = fmt.Sprintf("\033[1;38;5;46m🎉 %s wins the battle! TP: %.2f 🎉\033[0m", 
+			winMessage = fmt.Sprintf("\033[1;38;5;46m🎉 %s wins the battle! TP: %.2f 🎉\033[0m",
 				winner.Player.Name, *winner.Score.TP)
 		} else {
 			winMessage = "\033[1;38;5;248m🤝 It's a tie! Great battle! 🤝\033[0m"
 		}
-		
+
 		// Send to other players only
 		go func() {
 			room.mu.Lock()
@@ -404,8 +449,8 @@ func Duos(s glider.Session, p *player.Player) Scene {
 	shell.Write([]byte("\033[38;5;248m• Press Enter to return to lobby\033[0m\n"))
 	shell.Write([]byte("\033[38;5;248m• Type :main to return to main menu\033[0m\n"))
 	shell.Write([]byte("\033[38;5;208m> \033[0m"))
-	
-	_, nextScene, finished := SafeReadInput(shell, s, p)
+
+	_, nextScene, finished = SafeReadInput(shell, s, p)
 	if finished {
 		cancel()
 		if nextScene != nil {
@@ -413,12 +458,75 @@ func Duos(s glider.Session, p *player.Player) Scene {
 		}
 	}
 
-	// Reset game state for next round
-	getDuosBehavior().Reset()
-
 	return Lobby
 }
 
+// findOpponent queues p for a battle and waits for a match. Once matched the
+// player presses a key to go in. If they press Esc or disconnect instead,
+// done is true and nextScene is where to go.
+func findOpponent(s glider.Session, p *player.Player) (*Room, Scene, bool) {
+	shell := p.Shell
+	if shuttingDown.Load() {
+		shell.Write([]byte("\033[38;5;196m🔧 Server is restarting, no new battles can start.\033[0m\n"))
+		return nil, Main, true
+	}
+
+	match := duosQueue.join(p)
+	in := s.(*session)
+
+	// leave gets p out of the queue, or out of the room if a match came in
+	// at the same time
+	var room *Room
+	leave := func() {
+		if room == nil && !duosQueue.leave(p) {
+			room = <-match
+		}
+		if room != nil {
+			room.Leave <- p
+		}
+	}
+
+	for {
+		select {
+		case room = <-match:
+			opponent := "another player"
+			room.mu.Lock()
+			for name := range room.Players {
+				if name != p.Name {
+					opponent = name
+				}
+			}
+			room.mu.Unlock()
+			shell.Write(fmt.Appendf(nil, "\033[1;38;5;46m⚔️ Matched with %s! Press Enter to join the battle...\033[0m\n", opponent))
+			match = nil
+
+		case chunk, ok := <-in.keys():
+			switch {
+			case !ok || chunk.err != nil:
+				leave()
+				return nil, nil, true
+			case chunk.input == "\003": // Ctrl+C
+				leave()
+				s.Close()
+				return nil, nil, true
+			case chunk.input == "\033":
+				leave()
+				return nil, Main, true
+			case room != nil:
+				return room, nil, false
+			}
+		}
+	}
+}
+
+// opponentLeft reports whether a room is down to one player. Matched rooms
+// don't take new players, so the one left has to queue again.
+func opponentLeft(r *Room) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.Players)+len(r.Join) < 2
+}
+
 type PlayerResult struct {
 	Player   *player.Player
 	Score    *player.Score
@@ -427,13 +535,26 @@ type PlayerResult struct {
 }
 
 type DuosRoomBehavior struct {
-	gameStarted    bool
-	sentence       string
-	startTime      time.Time
-	gameStarting   bool
-	gameTimeLimit  time.Duration
-	playerResults  map[string]PlayerResult
-	mu             sync.Mutex
+	gameStarted bool
+	// What the next battle types from, words if nil
+	source util.TextSource
+	// Applied to generated words, Easy until someone picks another
+	difficulty util.Difficulty
+	// Language code of generated words, English if empty
+	language string
+	passage  util.Passage
+	// Name of the source passage came from, a pick during the battle only
+	// changes the next one
+	passageSource string
+	startTime     time.Time
+	gameStarting  bool
+	gameTimeLimit time.Duration
+	players       []*player.Player
+	playerResults map[string]PlayerResult
+	// Widgets of the players typing this battle, so a shutdown can score
+	// what they've typed so far
+	widgets map[string]*typingWidget
+	mu      sync.Mutex
 }
 
 func (d *DuosRoomBehavior) OnJoin(r *Room, p *player.Player) {
@@ -447,11 +568,15 @@ func (d *DuosRoomBehavior) OnLeave(r *Room, p *player.Player) {
 	r.Broadcast <- RoomMessage{"Server", fmt.Sprintf("\033[38;5;196m👋 %s left the duos arena. (%d players remaining)\033[0m", p.Name, playerCount)}
 	log.Printf("%s left the duos room. Remaining players: %d", p.Name, playerCount)
 
-	// Reset game if not enough players
+	// Reset game if not enough players. Nobody else joins a matched room, so
+	// before the battle the one left behind goes back to the queue.
 	if playerCount < 2 {
+		d.mu.Lock()
+		started := d.gameStarted
+		d.mu.Unlock()
 		d.Reset()
-		if playerCount > 0 {
-			r.Broadcast <- RoomMessage{"Server", "\033[38;5;248m🔄 Game reset - waiting for another player...\033[0m"}
+		if playerCount > 0 && !started {
+			r.Broadcast <- RoomMessage{"Server", "\033[38;5;248m🔄 Press Enter to look for a new opponent...\033[0m"}
 		}
 	}
 }
@@ -459,14 +584,14 @@ func (d *DuosRoomBehavior) OnLeave(r *Room, p *player.Player) {
 func (d *DuosRoomBehavior) OnMessage(r *Room, msg RoomMessage) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	
+
 	// Send message to all players except the sender
 	for _, p := range r.Players {
 		// Skip the sender to avoid double messages
 		if p.Name == msg.Sender {
 			continue
 		}
-		
+
 		if p.Messages != nil {
 			select {
 			case p.Messages <- msg.Content:
@@ -482,36 +607,90 @@ func (d *DuosRoomBehavior) OnMessage(r *Room, msg RoomMessage) {
 	}
 }
 
-func (d *DuosRoomBehavior) TryStartGame(r *Room) {
+// TryStartGame starts the battle once every player in r is ready. It errors
+// when there's no text to type, so the players aren't left waiting on it.
+func (d *DuosRoomBehavior) TryStartGame(r *Room) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	if d.gameStarted || d.gameStarting {
-		return
+	if d.gameStarted || d.gameStarting || shuttingDown.Load() {
+		return nil
 	}
 
 	r.mu.Lock()
 	readyCount := 0
 	totalPlayers := len(r.Players)
+	players := make([]*player.Player, 0, totalPlayers)
 	for _, p := range r.Players {
 		if p.Ready {
 			readyCount++
 		}
+		players = append(players, p)
 	}
 	r.mu.Unlock()
 
 	if totalPlayers < 2 || readyCount < totalPlayers {
-		return
+		return nil
 	}
 
+	// One seed for the whole room, so everyone types the same text
+	opts := util.TextOptions{Language: d.wordLanguage(), Difficulty: d.difficulty}
+	source := d.textSource()
+	passage, err := source.Passage(util.NewSeed(), opts)
+	if err != nil {
+		return err
+	}
 	d.gameStarting = true
-	d.sentence = util.GetSentences()
+	d.passage, d.passageSource = passage, source.Name()
 	d.gameStarted = true
 	d.startTime = time.Now()
+	d.players = players
 	d.playerResults = make(map[string]PlayerResult)
+	d.widgets = make(map[string]*typingWidget)
 
-	log.Printf("Duos game started with %d players, sentence: %s", totalPlayers, d.sentence)
+	log.Printf("Duos game started with %d players, seed %d, sentence: %s", totalPlayers, d.passage.Seed, d.passage.Text)
 	r.Broadcast <- RoomMessage{"Server", "\033[1;38;5;46m🚀 All players ready! Battle commencing...\033[0m"}
+	return nil
+}
+
+// SetSource picks what the next battle types. The room keeps it for later
+// battles until someone picks another.
+func (d *DuosRoomBehavior) SetSource(source util.TextSource) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.source = source
+}
+
+// SetDifficulty picks how the next battle's words are generated, kept like
+// SetSource.
+func (d *DuosRoomBehavior) SetDifficulty(difficulty util.Difficulty) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.difficulty = difficulty
+}
+
+// SetLanguage picks the language of the next battle's words, kept like
+// SetSource.
+func (d *DuosRoomBehavior) SetLanguage(language string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.language = language
+}
+
+// wordLanguage is the language for the next battle. Callers hold d.mu.
+func (d *DuosRoomBehavior) wordLanguage() string {
+	if d.language == "" {
+		return util.DefaultLanguage
+	}
+	return d.language
+}
+
+// textSource is the source for the next battle. Callers hold d.mu.
+func (d *DuosRoomBehavior) textSource() util.TextSource {
+	if d.source == nil {
+		return util.WordSource
+	}
+	return d.source
 }
 
 func (d *DuosRoomBehavior) Reset() {
@@ -519,7 +698,58 @@ func (d *DuosRoomBehavior) Reset() {
 	defer d.mu.Unlock()
 	d.gameStarted = false
 	d.gameStarting = false
-	d.sentence = ""
+	d.passage, d.passageSource = util.Passage{}, ""
+	d.players = nil
 	d.playerResults = make(map[string]PlayerResult)
+	d.widgets = make(map[string]*typingWidget)
 	log.Printf("Duos game state reset")
 }
+
+func (d *DuosRoomBehavior) MatchInProgress() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.gameStarted && len(d.playerResults) < len(d.players)
+}
+
+// SavePartialResults scores everyone who is still typing on what they've
+// typed so far, so a shutdown doesn't lose the match.
+func (d *DuosRoomBehavior) SavePartialResults(r *Room) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.gameStarted {
+		return
+	}
+
+	elapsed := min(time.Since(d.startTime), d.gameTimeLimit)
+	for _, p := range d.players {
+		if _, done := d.playerResults[p.Name]; done {
+			continue
+		}
+
+		// Someone still in the countdown hasn't typed anything yet
+		var keys player.KeystrokeLog
+		if w := d.widgets[p.Name]; w != nil {
+			keys = w.Keystrokes()
+		}
+		keys.Elapsed = elapsed
+		score := player.ScoreCalculation(d.passage.Text, keys, d.passage.Difficulty)
+		score.Source = d.passageSource
+		score.Language = d.passage.Language
+		score.Seed = d.passage.Seed
+
+		d.playerResults[p.Name] = PlayerResult{
+			Player:   p,
+			Score:    &score,
+			Input:    keys.Text(),
+			TimedOut: true,
+		}
+		if !p.Guest {
+			if err := player.SaveScore(p.ID, &score); err != nil {
+				log.Printf("Failed to save partial score for %s: %v", p.Name, err)
+			}
+		}
+		p.Scores = append(p.Scores, score)
+		log.Printf("Saved partial duos score for %s", p.Name)
+	}
+}
@@ -1,19 +1,122 @@
 package scenes
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"sort"
 	"ssh-battle/player"
+	"ssh-battle/stats"
 	"ssh-battle/util"
+	"strconv"
+	"strings"
 	"time"
 
 	glider "github.com/gliderlabs/ssh"
+	"golang.org/x/term"
 )
 
 func Game(s glider.Session, p *player.Player) Scene {
+	return playGame(s, p, 0)
+}
+
+// playGame is single player typing the text generated from seed, or new text
+// if it's 0.
+func playGame(s glider.Session, p *player.Player, seed int64) Scene {
+	if p.Mode == "" {
+		p.Mode = player.ModeSentence
+	}
+
 	shell := p.Shell
-	clearTerminal(shell)
 
+	// Options typed at the prompt are only applied, the run starts on an
+	// empty Enter so the player can check what they picked first
+	var notice string
+	for {
+		clearTerminal(shell)
+		renderGameOptions(shell, p, seed)
+		if notice != "" {
+			shell.Write([]byte(notice + "\n"))
+		}
+
+		shell.Write([]byte("\033[38;5;208m> \033[0m"))
+		input, nextScene, done := SafeReadInput(shell, s, p)
+		if done {
+			return nextScene
+		}
+		if strings.TrimSpace(input) == "" {
+			break
+		}
+		notice = applyGameOption(p, input)
+	}
+	mode := p.Mode
+	source := util.SourceByName(p.Source)
+	difficulty := p.Difficulty
+	language := p.Language
+	if seed == 0 {
+		seed = util.NewSeed()
+	}
+	opts := util.TextOptions{Language: language, Difficulty: difficulty}
+
+	shell.Write([]byte("\n"))
+	var passage util.Passage
+	if mode == player.ModeSentence {
+		var err error
+		passage, err = source.Passage(seed, opts)
+		if err != nil {
+			return noText(s, p, err)
+		}
+		writeAttribution(shell, passage)
+		// Quotes and code are typed as written
+		difficulty, language = passage.Difficulty, passage.Language
+	}
+	widget, err := newModeWidget(shell, mode, passage.Text, util.NewGenerator(seed, opts), termWidth(p))
+	if err != nil {
+		return noText(s, p, err)
+	}
+	keys, nextScene, done := widget.Run(s, p)
+	if done {
+		return nextScene
+	}
+
+	// Timed runs are scored on the text up to where the time ran out
+	sentence := widget.Target()
+	if mode.Seconds() > 0 {
+		sentence = string([]rune(sentence)[:min(len([]rune(keys.Text())), len([]rune(sentence)))])
+	}
+
+	score := player.ScoreCalculation(sentence, keys, difficulty)
+	score.Mode = mode
+	score.Language = language
+	score.Seed = seed
+	if mode == player.ModeSentence {
+		score.Source = source.Name()
+	}
+	if !p.Guest {
+		if err := player.SaveScore(p.ID, &score); err != nil {
+			log.Println("DB error saving score:", err)
+		}
+	}
+	p.Scores = append(p.Scores, score)
+
+	writeResults(shell, p.Scores[len(p.Scores)-1], termWidth(p))
+
+	shell.Write([]byte("\033[38;5;46mPress Enter to view your score list...\033[0m\n"))
+	shell.Write([]byte("\033[38;5;208m> \033[0m"))
+	_, nextScene, done = SafeReadInput(shell, s, p)
+	if done {
+		return nextScene
+	}
+
+	return ScoreList
+}
+
+// newModeWidget sets up the typing widget for a single player mode, sentence
+// mode types sentence. The other modes take their words from gen and show a
+// few wrapped lines that scroll as you type.
+// renderGameOptions shows the single player screen with the options the next
+// run is played with.
+func renderGameOptions(shell *term.Terminal, p *player.Player, seed int64) {
 	// Header
 	shell.Write([]byte("\033[38;5;45m┌────────────────────────────────────────────────┐\033[0m\n"))
 	shell.Write([]byte("\033[38;5;45m│ 🎮 \033[1;38;5;51mSingle Player Typing Game\033[0m\033[38;5;45m                 │\033[0m\n"))
@@ -23,46 +126,260 @@ func Game(s glider.Session, p *player.Player) Scene {
 	shell.Write([]byte("\033[38;5;229mInstructions:\033[0m\n"))
 	shell.Write([]byte("\033[38;5;252m──────────────\033[0m\n"))
 	shell.Write([]byte("\033[38;5;248m• Press Enter to start typing\033[0m\n"))
-	shell.Write([]byte("\033[38;5;248m• Type :q to quit or :help for more commands\033[0m\n\n"))
+	shell.Write([]byte("\033[38;5;248m• Mistakes turn red as you type, Backspace to fix them\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Type :q to quit or :help for more commands (Esc while typing)\033[0m\n\n"))
+
+	shell.Write([]byte("\033[38;5;229mMode:\033[0m\n"))
+	shell.Write([]byte("\033[38;5;252m─────\033[0m\n"))
+	shell.Write(fmt.Appendf(nil, "\033[38;5;248mPlaying: \033[1;38;5;51m%s\033[0m\033[38;5;248m · Difficulty: \033[1;38;5;51m%s\033[0m\033[38;5;248m · Language: \033[1;38;5;51m%s\033[0m\n",
+		modeLabel(string(p.Mode), p.Source), p.Difficulty, util.LanguageName(p.Language)))
+	if seed != 0 {
+		shell.Write(fmt.Appendf(nil, "\033[38;5;248mSeed: \033[1;38;5;51m%d\033[0m\033[38;5;248m, the same options type the same text\033[0m\n", seed))
+	}
+	shell.Write([]byte("\033[38;5;248mType a mode to switch: \033[38;5;51msentence\033[38;5;248m · \033[38;5;51m15s 30s 60s 120s\033[38;5;248m · \033[38;5;51m10w 25w 50w 100w\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248mOr a text for the sentence: \033[38;5;51mwords quotes code\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248mOr a difficulty for generated words: \033[38;5;51measy normal hard\033[38;5;248m (or toggles like \033[38;5;51mcaps+numbers\033[38;5;248m)\033[0m\n"))
+	shell.Write(fmt.Appendf(nil, "\033[38;5;248mOr a language: \033[38;5;51m%s\033[0m\n\n", strings.Join(util.Languages(), " ")))
 
 	shell.Write([]byte("\033[38;5;229mReady:\033[0m\n"))
 	shell.Write([]byte("\033[38;5;252m──────\033[0m\n"))
-	shell.Write([]byte("\033[38;5;46mPress Enter when you're ready...\033[0m\n"))
-	shell.Write([]byte("\033[38;5;208m> \033[0m"))
+	shell.Write([]byte("\033[38;5;46mPress Enter when you're ready, or type an option above to switch first...\033[0m\n"))
+}
 
-	sentence := util.GetSentences()
-	_, nextScene, done := SafeReadInput(shell, s, p)
-	if done {
-		return nextScene
+// applyGameOption switches the mode, text, difficulty or language the next
+// run is played with and returns what to tell the player.
+func applyGameOption(p *player.Player, input string) string {
+	// Only sentence runs use the text source, the others are always words
+	if mode, ok := player.ParseMode(input); ok {
+		p.Mode = mode
+		if mode != player.ModeSentence {
+			p.Source = util.WordSource.Name()
+		}
+		return fmt.Sprintf("\033[38;5;46m✅ You now play %s\033[0m", modeLabel(string(p.Mode), p.Source))
 	}
+	if source, ok := util.ParseSource(input); ok {
+		p.Source = source.Name()
+		p.Mode = player.ModeSentence
+		return fmt.Sprintf("\033[38;5;46m✅ You now play %s\033[0m", modeLabel(string(p.Mode), p.Source))
+	}
+	if difficulty, ok := util.ParseDifficulty(input); ok {
+		p.Difficulty = difficulty
+		return fmt.Sprintf("\033[38;5;46m✅ Words are now %s\033[0m", difficulty)
+	}
+	if language, ok := util.ParseLanguage(input); ok {
+		return switchLanguage(p, language)
+	}
+	return fmt.Sprintf("\033[38;5;196m❌ Unknown mode, text, difficulty or language %q\033[0m", strings.TrimSpace(input))
+}
 
-	shell.Write([]byte("\033[38;5;252m\n" + sentence + "\033[0m\n"))
-	shell.Write([]byte("\033[38;5;208m> \033[0m"))
-	start := time.Now()
+func newModeWidget(shell *term.Terminal, mode player.Mode, sentence string, gen *util.Generator, width int) (*typingWidget, error) {
+	switch {
+	case mode.Seconds() > 0:
+		words, err := gen.Words(50)
+		if err != nil {
+			return nil, err
+		}
+		w := newTypingWidget(shell, strings.Join(words, " "), time.Duration(mode.Seconds())*time.Second)
+		w.feed = func() string {
+			words, err := gen.Words(10)
+			if err != nil {
+				log.Println("Error generating words:", err)
+				return ""
+			}
+			return strings.Join(words, " ")
+		}
+		w.hint = "Type until the time runs out · Backspace to correct · Esc for commands"
+		w.lines, w.width = 3, width
+		return w, nil
+	case mode.Words() > 0:
+		words, err := gen.Words(mode.Words())
+		if err != nil {
+			return nil, err
+		}
+		return newRunWidget(shell, mode, strings.Join(words, " "), width), nil
+	default:
+		return newTypingWidget(shell, sentence, 0), nil
+	}
+}
 
-	input, nextScene, done := SafeReadInput(shell, s, p)
-	if done {
-		return nextScene
+// newRunWidget sets up the typing widget for the text of a run that was
+// played in mode, like a replay, scrolling it the way newModeWidget does.
+func newRunWidget(shell *term.Terminal, mode player.Mode, text string, width int) *typingWidget {
+	w := newTypingWidget(shell, text, 0)
+	if mode.Seconds() > 0 || mode.Words() > 0 {
+		w.lines, w.width = 3, width
 	}
+	return w
+}
 
-	elapsed := time.Since(start)
-	score := player.ScoreCalculation(sentence, input, elapsed)
-	p.Scores = append(p.Scores, score)
-	player.SaveScore(p.ID, score)
+// writeAttribution says where a quote or snippet comes from before typing it.
+func writeAttribution(shell *term.Terminal, passage util.Passage) {
+	if passage.Attribution != "" {
+		shell.Write(fmt.Appendf(nil, "\033[38;5;248m📜 %s\033[0m\n", passage.Attribution))
+	}
+}
+
+// modeLabel names a score's format for tables, the text source for sentence
+// runs that weren't typed from words.
+func modeLabel(mode, source string) string {
+	if source != "" && source != util.WordSource.Name() {
+		return strings.ToUpper(source[:1]) + source[1:]
+	}
+	if mode == "" {
+		return player.ModeSentence.String()
+	}
+	return player.Mode(mode).String()
+}
+
+// gameArgs handles ":game 30s", ":game hard" and ":game 30s hard" to start
+// single player with a given mode and difficulty. A text, a language and
+// "seed:123" are accepted too, see gameCommand.
+func gameArgs(args []string) (Scene, error) {
+	var mode player.Mode
+	var source util.TextSource
+	var difficulty *util.Difficulty
+	var language string
+	var seed int64
+	for _, arg := range args {
+		if m, ok := player.ParseMode(arg); ok && mode == "" {
+			mode = m
+		} else if src, ok := util.ParseSource(arg); ok && source == nil {
+			source = src
+		} else if d, ok := util.ParseDifficulty(arg); ok && difficulty == nil {
+			difficulty = &d
+		} else if l, ok := util.ParseLanguage(arg); ok && language == "" {
+			language = l
+		} else if n, ok := parseSeed(arg); ok && seed == 0 {
+			seed = n
+		} else {
+			return nil, fmt.Errorf("unknown mode, text, difficulty, language or seed %q", arg)
+		}
+	}
+	if source != nil && mode != "" && mode != player.ModeSentence {
+		return nil, errors.New("only sentence runs can type quotes or code")
+	}
+
+	return func(s glider.Session, p *player.Player) Scene {
+		if mode != "" {
+			p.Mode = mode
+			if mode != player.ModeSentence {
+				p.Source = util.WordSource.Name()
+			}
+		}
+		if source != nil {
+			p.Mode, p.Source = player.ModeSentence, source.Name()
+		}
+		if difficulty != nil {
+			p.Difficulty = *difficulty
+		}
+		if language != "" {
+			if err := p.SetLanguage(language); err != nil {
+				log.Println("DB error saving language:", err)
+			}
+		}
+		return playGame(s, p, seed)
+	}, nil
+}
+
+// parseSeed accepts "seed:123", how gameCommand writes seeds.
+func parseSeed(s string) (int64, bool) {
+	n, ok := strings.CutPrefix(strings.ToLower(s), "seed:")
+	if !ok {
+		return 0, false
+	}
+	seed, err := strconv.ParseInt(n, 10, 64)
+	return seed, err == nil && seed != 0
+}
+
+// gameCommand is the :game command that types score's text again, "" if it
+// can't be generated again. Daily challenges are retried from their own scene.
+func gameCommand(score player.Score) string {
+	if score.Seed == 0 || score.Mode == player.ModeDaily {
+		return ""
+	}
 
-	last := p.Scores[len(p.Scores)-1]
+	args := []string{":game"}
+	mode := score.Mode
+	switch {
+	case score.Source != "" && score.Source != util.WordSource.Name():
+		// Quotes and code are typed as written
+		return fmt.Sprintf(":game %s seed:%d", score.Source, score.Seed)
+	case mode.Seconds() > 0:
+		args = append(args, fmt.Sprintf("%ds", mode.Seconds()))
+	case mode.Words() > 0:
+		args = append(args, fmt.Sprintf("%dw", mode.Words()))
+	default:
+		args = append(args, "sentence")
+	}
+	language := score.Language
+	if language == "" {
+		language = util.DefaultLanguage
+	}
+	args = append(args, score.Difficulty.String(), language, fmt.Sprintf("seed:%d", score.Seed))
+	return strings.Join(args, " ")
+}
+
+// writeResults prints the stats of a finished single player run and how it
+// lines up with the sentence.
+func writeResults(shell *term.Terminal, last player.Score, width int) {
 	shell.Write([]byte("\033[38;5;229m\nYour Results:\033[0m\n"))
 	shell.Write([]byte("\033[38;5;252m─────────────\033[0m\n"))
 	shell.Write(fmt.Appendf(nil, "\033[38;5;248mAccuracy: \033[38;5;51m%.2f%%\033[0m\n", *last.Accuracy))
-	shell.Write(fmt.Appendf(nil, "\033[38;5;248mWPM: \033[38;5;51m%.1f\033[0m\n", *last.WPM))
+	shell.Write(fmt.Appendf(nil, "\033[38;5;248mWPM: \033[38;5;51m%.1f\033[0m\033[38;5;248m net · \033[38;5;51m%.1f\033[0m\033[38;5;248m raw\033[0m\n", *last.WPM, *last.RawWPM))
+	shell.Write(fmt.Appendf(nil, "\033[38;5;248mConsistency: \033[38;5;51m%.0f%%\033[0m \033[38;5;45m%s\033[0m\n", *last.Consistency, sparkline(last.WPMSamples)))
+	shell.Write(fmt.Appendf(nil, "\033[38;5;248mErrors: \033[38;5;51m%d\033[0m\033[38;5;248m corrected · \033[38;5;51m%d\033[0m\033[38;5;248m uncorrected (%d extra, %d missed, %d wrong)\033[0m\n",
+		*last.CorrectedErrors, *last.UncorrectedErrors, *last.Insertions, *last.Deletions, *last.Substitutions))
 	shell.Write(fmt.Appendf(nil, "\033[38;5;248mTime: \033[38;5;51m%d seconds\033[0m\n", *last.Duration))
-	shell.Write(fmt.Appendf(nil, "\033[38;5;248mTP Score: \033[38;5;51m%.2f\033[0m\n\n", *last.TP))
+	if last.Difficulty != util.Easy {
+		shell.Write(fmt.Appendf(nil, "\033[38;5;248mTP Score: \033[38;5;51m%.2f\033[0m\033[38;5;248m (×%.2f for %s)\033[0m\n\n", *last.TP, last.Difficulty.Multiplier(), last.Difficulty))
+	} else {
+		shell.Write(fmt.Appendf(nil, "\033[38;5;248mTP Score: \033[38;5;51m%.2f\033[0m\n\n", *last.TP))
+	}
+	if last.Sentence != nil && last.Keys != nil {
+		shell.Write([]byte("\033[38;5;229mYour Attempt:\033[0m\n"))
+		shell.Write([]byte("\033[38;5;252m─────────────\033[0m\n"))
+		writeDiff(shell, player.Compare(*last.Sentence, last.Keys.Text()), width)
+	}
+	if last.HasReplay() {
+		shell.Write(fmt.Appendf(nil, "\033[38;5;248m🎬 Watch it back with \033[1;38;5;51m:replay %d\033[0m\n\n", *last.ID))
+	}
+	if cmd := gameCommand(last); cmd != "" {
+		shell.Write(fmt.Appendf(nil, "\033[38;5;248m🌱 Type the same text again, or share it, with \033[1;38;5;51m%s\033[0m\n\n", cmd))
+	}
+}
 
-	shell.Write([]byte("\033[38;5;46mPress Enter to view your score list...\033[0m\n"))
-	shell.Write([]byte("\033[38;5;208m> \033[0m"))
-	shell.ReadLine()
+// sparkline draws per second WPM samples as a row of bars, averaging
+// neighbours so long runs still fit on one line.
+func sparkline(samples []float64) string {
+	const width = 60
+	if len(samples) > width {
+		per := (len(samples) + width - 1) / width
+		grouped := make([]float64, 0, width)
+		for i := 0; i < len(samples); i += per {
+			group := samples[i:min(i+per, len(samples))]
+			sum := 0.0
+			for _, v := range group {
+				sum += v
+			}
+			grouped = append(grouped, sum/float64(len(group)))
+		}
+		samples = grouped
+	}
 
-	return ScoreList
+	bars := []rune("▁▂▃▄▅▆▇█")
+	top := 0.0
+	for _, v := range samples {
+		top = max(top, v)
+	}
+	if top == 0 {
+		return ""
+	}
+
+	line := make([]rune, len(samples))
+	for i, v := range samples {
+		line[i] = bars[int(v/top*float64(len(bars)-1))]
+	}
+	return string(line)
 }
 
 func ScoreList(s glider.Session, p *player.Player) Scene {
@@ -78,28 +395,27 @@ func ScoreList(s glider.Session, p *player.Player) Scene {
 	shell.Write([]byte("\033[38;5;229mInstructions:\033[0m\n"))
 	shell.Write([]byte("\033[38;5;252m──────────────\033[0m\n"))
 	shell.Write([]byte("\033[38;5;248m• Press Enter to return to game\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Type :replay <run #> to watch a run again\033[0m\n"))
 	shell.Write([]byte("\033[38;5;248m• Type :q to quit or :help for more commands\033[0m\n\n"))
 
 	shell.Write([]byte("\033[38;5;229mTop 5 Scores:\033[0m\n"))
 	shell.Write([]byte("\033[38;5;252m──────────────\033[0m\n"))
 
-	sort.Slice(p.Scores, func(i, j int) bool {
-		return *p.Scores[i].TP > *p.Scores[j].TP
-	})
+	scores, err := topScores(p, 5)
+	if err != nil {
+		log.Println("DB error loading scores:", err)
+		shell.Write([]byte("\033[38;5;196mCan't load your scores right now.\033[0m\n\n"))
+	}
 
-	if len(p.Scores) == 0 {
+	if len(scores) == 0 {
 		shell.Write([]byte("\033[38;5;248mNo scores yet. Play a game to start!\033[0m\n\n"))
 	} else {
 		// Table header
-		shell.Write([]byte("\033[38;5;45m┌───────┬──────────┬───────┬───────────┬───────────┐\033[0m\n"))
-		shell.Write([]byte("\033[38;5;45m│ Rank  │ Accuracy │ WPM   │ Time (s)  │ TP Score  │\033[0m\n"))
-		shell.Write([]byte("\033[38;5;45m├───────┼──────────┼───────┼───────────┼───────────┤\033[0m\n"))
-
-		for i, score := range p.Scores {
-			if i >= 5 {
-				break
-			}
+		shell.Write([]byte("\033[38;5;45m┌───────┬────────┬──────────┬──────────┬───────┬───────────┬───────────┐\033[0m\n"))
+		shell.Write([]byte("\033[38;5;45m│ Rank  │ Run #  │ Mode     │ Accuracy │ WPM   │ Time (s)  │ TP Score  │\033[0m\n"))
+		shell.Write([]byte("\033[38;5;45m├───────┼────────┼──────────┼──────────┼───────┼───────────┼───────────┤\033[0m\n"))
 
+		for i, score := range scores {
 			rankColor := "\033[38;5;252m" // default grey
 			switch i {
 			case 0:
@@ -111,18 +427,20 @@ func ScoreList(s glider.Session, p *player.Player) Scene {
 			}
 
 			row := fmt.Sprintf(
-				"%s│ %-5d │ %8.2f │ %5.1f │ %9d │ %9.2f │\033[0m\n",
+				"%s│ %-5d │ %-6s │ %-8s │ %8.2f │ %5.1f │ %9d │ %9.2f │\033[0m\n",
 				rankColor,
 				i+1,
-				*score.Accuracy,
-				*score.WPM,
-				*score.Duration,
-				*score.TP,
+				runNumber(score.ScoreID),
+				modeLabel(score.Mode, score.Source),
+				score.Accuracy,
+				score.WPM,
+				score.Duration,
+				score.TP,
 			)
 			shell.Write([]byte(row))
 		}
 
-		shell.Write([]byte("\033[38;5;45m└───────┴──────────┴───────┴───────────┴───────────┘\033[0m\n\n"))
+		shell.Write([]byte("\033[38;5;45m└───────┴────────┴──────────┴──────────┴───────┴───────────┴───────────┘\033[0m\n\n"))
 	}
 
 	// Footer prompt
@@ -135,3 +453,48 @@ func ScoreList(s glider.Session, p *player.Player) Scene {
 
 	return Game
 }
+
+// topScores returns the player's n best scores. Guests only have the ones from
+// this session.
+func topScores(p *player.Player, n int) ([]stats.Entry, error) {
+	if !p.Guest {
+		return stats.Default().PlayerTop(p.ID, n)
+	}
+
+	// Practice runs aren't ranked, like on the leaderboards
+	var session []player.Score
+	for _, score := range p.Scores {
+		if !score.Practice {
+			session = append(session, score)
+		}
+	}
+	sort.Slice(session, func(i, j int) bool {
+		return *session[i].TP > *session[j].TP
+	})
+
+	entries := make([]stats.Entry, 0, n)
+	for i, score := range session {
+		if i >= n {
+			break
+		}
+		entries = append(entries, stats.Entry{
+			Rank:       i + 1,
+			PlayerName: p.Name,
+			Accuracy:   *score.Accuracy,
+			WPM:        *score.WPM,
+			TP:         *score.TP,
+			Duration:   *score.Duration,
+			Mode:       string(score.Mode),
+			Source:     score.Source,
+		})
+	}
+	return entries, nil
+}
+
+// runNumber formats a score ID for tables, "-" for rows that aren't one saved run.
+func runNumber(scoreID int) string {
+	if scoreID == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("#%d", scoreID)
+}
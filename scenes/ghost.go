@@ -0,0 +1,137 @@
+package scenes
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"ssh-battle/player"
+	"strconv"
+	"strings"
+
+	glider "github.com/gliderlabs/ssh"
+)
+
+// GhostRace races the player's personal best ranked run in the mode and text
+// they picked last.
+func GhostRace(s glider.Session, p *player.Player) Scene {
+	// Only sentence runs are saved with the source they were picked from
+	source := p.Source
+	if p.Mode != "" && p.Mode != player.ModeSentence {
+		source = ""
+	}
+	replay, err := p.BestReplay(p.Mode, source)
+	if err != nil {
+		if err != player.ErrReplayNotFound {
+			log.Println("DB error loading best replay:", err)
+		}
+		shell := p.Shell
+		clearTerminal(shell)
+		shell.Write([]byte(fmt.Sprintf("\033[38;5;248mNo ranked %s runs to race yet. Play a single player game first!\033[0m\n\n", modeLabel(string(p.Mode), source))))
+		shell.Write([]byte("\033[38;5;46mPress Enter to return to the menu...\033[0m\n"))
+		shell.Write([]byte("\033[38;5;208m> \033[0m"))
+		_, nextScene, done := SafeReadInput(shell, s, p)
+		if done {
+			return nextScene
+		}
+		return Main
+	}
+	return raceGhost(s, p, replay)
+}
+
+// ghostArgs handles ":ghost <run #>" to race any saved run.
+func ghostArgs(args []string) (Scene, error) {
+	id, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	if err != nil || id <= 0 {
+		return nil, fmt.Errorf("%q isn't a run number", args[0])
+	}
+
+	replay, err := player.LoadReplay(id)
+	if err == player.ErrReplayNotFound {
+		return nil, fmt.Errorf("run #%d has no replay to race", id)
+	}
+	if err != nil {
+		log.Println("DB error loading replay:", err)
+		return nil, errors.New("can't load that run right now")
+	}
+
+	return func(s glider.Session, p *player.Player) Scene {
+		return raceGhost(s, p, replay)
+	}, nil
+}
+
+func raceGhost(s glider.Session, p *player.Player, ghost player.Replay) Scene {
+	shell := p.Shell
+	clearTerminal(shell)
+
+	// Header
+	shell.Write([]byte("\033[38;5;45m┌────────────────────────────────────────────────┐\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m│ 👻 \033[1;38;5;51mGhost Race\033[0m\033[38;5;45m                                │\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m└────────────────────────────────────────────────┘\033[0m\n\n"))
+
+	shell.Write([]byte("\033[38;5;229mInstructions:\033[0m\n"))
+	shell.Write([]byte("\033[38;5;252m──────────────\033[0m\n"))
+	if ghost.ScoreID != 0 {
+		shell.Write(fmt.Appendf(nil, "\033[38;5;248m• You're racing run \033[1;38;5;51m#%d\033[0m\033[38;5;248m by \033[1;38;5;51m%s\033[0m\n", ghost.ScoreID, ghost.PlayerName))
+	} else {
+		shell.Write([]byte("\033[38;5;248m• You're racing your best run of this session\033[0m\n"))
+	}
+	shell.Write([]byte("\033[38;5;248m• The \033[48;5;54;38;5;252mpurple\033[0m\033[38;5;248m cursor is the ghost, typing at its recorded pace\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Ghost races are practice, they're saved but stay off the leaderboard\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Type :q to quit or :help for more commands (Esc while typing)\033[0m\n\n"))
+
+	shell.Write([]byte("\033[38;5;46mPress Enter when you're ready...\033[0m\n"))
+	shell.Write([]byte("\033[38;5;208m> \033[0m"))
+	_, nextScene, done := SafeReadInput(shell, s, p)
+	if done {
+		return nextScene
+	}
+
+	shell.Write([]byte("\n"))
+	widget := newRunWidget(shell, ghost.Mode, ghost.Sentence, termWidth(p))
+	widget.ghost = &ghost.Keys
+	keys, nextScene, done := widget.Run(s, p)
+	if done {
+		return nextScene
+	}
+
+	score := player.ScoreCalculation(ghost.Sentence, keys, ghost.Difficulty)
+	score.Mode = ghost.Mode
+	score.Source = ghost.Source
+	score.Language = ghost.Language
+	score.Seed = ghost.Seed
+	// The text is known in advance and can be raced again and again, so
+	// ghost races stay off the leaderboards
+	score.Practice = true
+	if !p.Guest {
+		if err := player.SaveScore(p.ID, &score); err != nil {
+			log.Println("DB error saving score:", err)
+		}
+	}
+	p.Scores = append(p.Scores, score)
+
+	// Score the ghost the same way so the comparison is fair
+	ghostScore := player.ScoreCalculation(ghost.Sentence, ghost.Keys, ghost.Difficulty)
+	writeResults(shell, score, termWidth(p))
+
+	shell.Write([]byte("\033[38;5;229mVersus the Ghost:\033[0m\n"))
+	shell.Write([]byte("\033[38;5;252m─────────────────\033[0m\n"))
+	shell.Write(fmt.Appendf(nil, "\033[38;5;248mTime: \033[38;5;51m%.1fs\033[0m\033[38;5;248m vs \033[38;5;141m%.1fs\033[0m\n", keys.Elapsed.Seconds(), ghost.Keys.Elapsed.Seconds()))
+	shell.Write(fmt.Appendf(nil, "\033[38;5;248mTP Score: \033[38;5;51m%.2f\033[0m\033[38;5;248m vs \033[38;5;141m%.2f\033[0m\n", *score.TP, *ghostScore.TP))
+	switch {
+	case *score.TP > *ghostScore.TP:
+		shell.Write([]byte("\033[1;38;5;46m🏆 You beat the ghost!\033[0m\n\n"))
+	case *score.TP < *ghostScore.TP:
+		shell.Write([]byte("\033[1;38;5;196m👻 The ghost wins this time.\033[0m\n\n"))
+	default:
+		shell.Write([]byte("\033[1;38;5;248m🤝 Dead heat with the ghost!\033[0m\n\n"))
+	}
+
+	shell.Write([]byte("\033[38;5;46mPress Enter to view your score list...\033[0m\n"))
+	shell.Write([]byte("\033[38;5;208m> \033[0m"))
+	_, nextScene, done = SafeReadInput(shell, s, p)
+	if done {
+		return nextScene
+	}
+
+	return ScoreList
+}
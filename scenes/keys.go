@@ -0,0 +1,114 @@
+package scenes
+
+import (
+	"fmt"
+	"log"
+	"ssh-battle/player"
+	"strconv"
+	"strings"
+
+	glider "github.com/gliderlabs/ssh"
+	"golang.org/x/term"
+)
+
+func Keys(s glider.Session, p *player.Player) Scene {
+	shell := p.Shell
+	clearTerminal(shell)
+
+	if p.Guest {
+		shell.Write([]byte("\033[38;5;196mGuests can't register SSH keys. Reconnect and create an account first.\033[0m\n\n"))
+		shell.Write([]byte("\033[38;5;46mPress Enter to return to main menu...\033[0m\n"))
+		shell.Write([]byte("\033[38;5;208m> \033[0m"))
+		_, nextScene, done := SafeReadInput(shell, s, p)
+		if done {
+			return nextScene
+		}
+		return Main
+	}
+
+	renderKeys(shell, p)
+
+	for {
+		shell.Write([]byte("\033[38;5;208m> \033[0m"))
+		input, nextScene, done := SafeReadInput(shell, s, p)
+		if done {
+			return nextScene
+		}
+
+		action, arg, _ := strings.Cut(strings.TrimSpace(input), " ")
+		arg = strings.TrimSpace(arg)
+
+		switch action {
+		case "":
+			return Main
+		case "add":
+			key, err := player.AddKey(p.ID, arg)
+			if err != nil {
+				shell.Write(fmt.Appendf(nil, "\033[38;5;196m❌ Could not add key: %v\033[0m\n", err))
+				continue
+			}
+			clearTerminal(shell)
+			renderKeys(shell, p)
+			shell.Write(fmt.Appendf(nil, "\033[38;5;46m✅ Added %s %s\033[0m\n", key.Type, key.Fingerprint))
+		case "revoke":
+			keys, err := player.ListKeys(p.ID)
+			if err != nil {
+				log.Println("DB error listing keys:", err)
+				shell.Write([]byte("\033[38;5;196m❌ Can't load your keys right now.\033[0m\n"))
+				continue
+			}
+
+			// Accept either the list number or the full fingerprint
+			fingerprint := arg
+			if n, err := strconv.Atoi(arg); err == nil && n >= 1 && n <= len(keys) {
+				fingerprint = keys[n-1].Fingerprint
+			}
+
+			if err := player.RevokeKey(p.ID, fingerprint); err != nil {
+				shell.Write(fmt.Appendf(nil, "\033[38;5;196m❌ Could not revoke key: %v\033[0m\n", err))
+				continue
+			}
+			clearTerminal(shell)
+			renderKeys(shell, p)
+			shell.Write(fmt.Appendf(nil, "\033[38;5;46m✅ Revoked %s\033[0m\n", fingerprint))
+		default:
+			shell.Write([]byte("\033[38;5;196m❌ Use 'add <public key>' or 'revoke <number>'.\033[0m\n"))
+		}
+	}
+}
+
+func renderKeys(shell *term.Terminal, p *player.Player) {
+	// Header
+	shell.Write([]byte("\033[38;5;45m┌────────────────────────────────────────────────┐\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m│ 🔑 \033[1;38;5;51mYour SSH Keys\033[0m\033[38;5;45m                               │\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m└────────────────────────────────────────────────┘\033[0m\n\n"))
+
+	// Instructions
+	shell.Write([]byte("\033[38;5;229mInstructions:\033[0m\n"))
+	shell.Write([]byte("\033[38;5;252m──────────────\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Type \033[1;38;5;51madd <public key>\033[0m\033[38;5;248m to register a key (paste a line from ~/.ssh/id_*.pub)\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Type \033[1;38;5;51mrevoke <number>\033[0m\033[38;5;248m to remove a key\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Press Enter to return to main menu\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Type :q to quit or :help for more commands\033[0m\n\n"))
+
+	shell.Write([]byte("\033[38;5;229mRegistered Keys:\033[0m\n"))
+	shell.Write([]byte("\033[38;5;252m────────────────\033[0m\n"))
+
+	keys, err := player.ListKeys(p.ID)
+	if err != nil {
+		log.Println("DB error listing keys:", err)
+		shell.Write([]byte("\033[38;5;196mCan't load your keys right now.\033[0m\n\n"))
+		return
+	}
+
+	if len(keys) == 0 {
+		shell.Write([]byte("\033[38;5;248mNo keys yet. You log in with your password.\033[0m\n\n"))
+		return
+	}
+
+	for i, k := range keys {
+		shell.Write(fmt.Appendf(nil, "\033[1;38;5;51m%2d.\033[0m \033[38;5;252m%-20s %s\033[0m\n", i+1, k.Type, k.Fingerprint))
+		shell.Write(fmt.Appendf(nil, "    \033[38;5;240m%s added %s\033[0m\n", k.Comment, k.CreatedAt.Format("2006-01-02")))
+	}
+	shell.Write([]byte("\n"))
+}
@@ -0,0 +1,95 @@
+package scenes
+
+import (
+	"fmt"
+	"log"
+	"ssh-battle/player"
+	"ssh-battle/util"
+	"strings"
+
+	glider "github.com/gliderlabs/ssh"
+	"golang.org/x/term"
+)
+
+// Language lets the player pick the language their words are generated in.
+func Language(s glider.Session, p *player.Player) Scene {
+	return showLanguages(s, p, "")
+}
+
+// languageArgs handles ":language nl" to switch without typing it in the menu.
+func languageArgs(args []string) (Scene, error) {
+	language, ok := util.ParseLanguage(args[0])
+	if !ok {
+		return nil, fmt.Errorf("no words for %q", args[0])
+	}
+
+	return func(s glider.Session, p *player.Player) Scene {
+		return showLanguages(s, p, language)
+	}, nil
+}
+
+// showLanguages lists the languages, switching to pick first unless it's empty.
+func showLanguages(s glider.Session, p *player.Player, pick string) Scene {
+	shell := p.Shell
+
+	var notice string
+	if pick != "" {
+		notice = switchLanguage(p, pick)
+	}
+	for {
+		clearTerminal(shell)
+		renderLanguages(shell, p)
+		if notice != "" {
+			shell.Write([]byte(notice + "\n"))
+		}
+
+		shell.Write([]byte("\033[38;5;208m> \033[0m"))
+		input, nextScene, done := SafeReadInput(shell, s, p)
+		if done {
+			return nextScene
+		}
+		if strings.TrimSpace(input) == "" {
+			return Main
+		}
+
+		language, ok := util.ParseLanguage(input)
+		if !ok {
+			notice = fmt.Sprintf("\033[38;5;196m❌ No words for %q. Pick one of the languages above.\033[0m", strings.TrimSpace(input))
+			continue
+		}
+		notice = switchLanguage(p, language)
+	}
+}
+
+// switchLanguage sets the player's language and returns what to tell them.
+func switchLanguage(p *player.Player, language string) string {
+	if err := p.SetLanguage(language); err != nil {
+		log.Println("DB error saving language:", err)
+		return "\033[38;5;208m⚠ Switched for this session, but it couldn't be saved.\033[0m"
+	}
+	return fmt.Sprintf("\033[38;5;46m✅ You now type in %s\033[0m", util.LanguageName(language))
+}
+
+func renderLanguages(shell *term.Terminal, p *player.Player) {
+	shell.Write([]byte("\033[38;5;45m┌────────────────────────────────────────────────┐\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m│ 🌍 \033[1;38;5;51mLanguage\033[0m\033[38;5;45m                                  │\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m└────────────────────────────────────────────────┘\033[0m\n\n"))
+
+	shell.Write([]byte("\033[38;5;229mInstructions:\033[0m\n"))
+	shell.Write([]byte("\033[38;5;252m──────────────\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Type a language code or name to switch\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Words in every mode, practice included, are generated in it\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Each language has its own leaderboard\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Press Enter to return to the menu\033[0m\n\n"))
+
+	shell.Write([]byte("\033[38;5;229mLanguages:\033[0m\n"))
+	shell.Write([]byte("\033[38;5;252m──────────\033[0m\n"))
+	for _, code := range util.Languages() {
+		if code == p.Language {
+			shell.Write(fmt.Appendf(nil, "\033[1;38;5;46m▶ %-4s %s\033[0m\n", code, util.LanguageName(code)))
+		} else {
+			shell.Write(fmt.Appendf(nil, "\033[38;5;248m  %-4s %s\033[0m\n", code, util.LanguageName(code)))
+		}
+	}
+	shell.Write([]byte("\n"))
+}
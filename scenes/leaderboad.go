@@ -3,14 +3,197 @@ package scenes
 import (
 	"fmt"
 	"log"
-	"ssh-battle/data"
+	"slices"
 	"ssh-battle/player"
+	"ssh-battle/stats"
+	"ssh-battle/util"
+	"time"
 
 	glider "github.com/gliderlabs/ssh"
+	"golang.org/x/term"
 )
 
+// leaderboardPageSize is how many rows the Leaderboard scene shows at once.
+const leaderboardPageSize = 10
+
+// leaderboardView is what the Leaderboard scene currently shows.
+type leaderboardView struct {
+	window  stats.Window
+	ranking stats.Ranking
+	mode    player.Mode
+	source  util.TextSource
+	// Language code of word runs, the player's own language if empty
+	language string
+	// Rows scrolled past
+	offset int
+	// Shown under the table once, e.g. when jumping to yourself fails
+	notice string
+}
+
 func Leaderboard(s glider.Session, p *player.Player) Scene {
+	return showLeaderboard(s, p, newLeaderboardView())
+}
+
+func newLeaderboardView() leaderboardView {
+	return leaderboardView{window: stats.AllTime, ranking: stats.RankBest, mode: player.ModeSentence, source: util.WordSource}
+}
+
+// leaderboardArgs handles ":leaderboard week", ":leaderboard runs",
+// ":leaderboard 30s", ":leaderboard quotes", ":leaderboard de" and
+// combinations like ":leaderboard week runs 30s".
+func leaderboardArgs(args []string) (Scene, error) {
+	view := newLeaderboardView()
+	for _, arg := range args {
+		if window, ok := stats.ParseWindow(arg); ok {
+			view.window = window
+		} else if ranking, ok := stats.ParseRanking(arg); ok {
+			view.ranking = ranking
+		} else if mode, ok := player.ParseMode(arg); ok {
+			view.mode = mode
+		} else if source, ok := util.ParseSource(arg); ok {
+			view.source = source
+		} else if language, ok := util.ParseLanguage(arg); ok {
+			view.language = language
+		} else {
+			return nil, fmt.Errorf("unknown period, ranking, mode, text or language %q", arg)
+		}
+	}
+
+	// Quotes and code are only typed in sentence runs, and only in English
+	if view.source != util.WordSource && view.mode != player.ModeSentence {
+		return nil, fmt.Errorf("%s are only typed in sentence mode", view.source.Name())
+	}
+	if view.source != util.WordSource && view.language != "" {
+		return nil, fmt.Errorf("%s aren't split by language", view.source.Name())
+	}
+
+	return func(s glider.Session, p *player.Player) Scene {
+		return showLeaderboard(s, p, view)
+	}, nil
+}
+
+func (view leaderboardView) query() stats.Query {
+	return stats.Query{
+		Ranking: view.ranking,
+		Mode:    string(view.mode),
+		Source:  view.source.Name(),
+		// Quotes and code are the same text whatever language you play in
+		Language: view.wordLanguage(),
+		Since:    view.window.Since(time.Now()),
+		Limit:    leaderboardPageSize,
+		Offset:   view.offset,
+	}
+}
+
+// wordLanguage is the language filter of the board, none for quotes and
+// code.
+func (view leaderboardView) wordLanguage() string {
+	if view.source != util.WordSource {
+		return ""
+	}
+	return view.language
+}
+
+func showLeaderboard(s glider.Session, p *player.Player, view leaderboardView) Scene {
 	shell := p.Shell
+	if view.language == "" {
+		view.language = p.Language
+	}
+	if view.language == "" {
+		view.language = util.DefaultLanguage
+	}
+
+	for {
+		total := renderLeaderboard(shell, p, view)
+		view.notice = ""
+
+		key, err := readKey(s)
+		if err != nil {
+			s.Close()
+			return nil
+		}
+
+		switch key {
+		case "left":
+			view.window = stats.Windows[(int(view.window)+len(stats.Windows)-1)%len(stats.Windows)]
+			view.offset = 0
+		case "right", "tab":
+			view.window = stats.Windows[(int(view.window)+1)%len(stats.Windows)]
+			view.offset = 0
+		case "r", "R":
+			view.ranking = stats.Rankings[(int(view.ranking)+1)%len(stats.Rankings)]
+			view.offset = 0
+		case "f", "F":
+			view.mode = player.Modes[(slices.Index(player.Modes, view.mode)+1)%len(player.Modes)]
+			if view.mode != player.ModeSentence {
+				view.source = util.WordSource
+			}
+			view.offset = 0
+		case "s", "S":
+			view.source = util.Sources[(slices.Index(util.Sources, view.source)+1)%len(util.Sources)]
+			if view.source != util.WordSource {
+				view.mode = player.ModeSentence
+			}
+			view.offset = 0
+		case "l", "L":
+			languages := util.Languages()
+			view.language = languages[(slices.Index(languages, view.language)+1)%len(languages)]
+			view.source = util.WordSource
+			view.offset = 0
+		case "down", "j", "J":
+			view.offset = clampOffset(view.offset+1, total)
+		case "up", "k", "K":
+			view.offset = clampOffset(view.offset-1, total)
+		case "pgdown":
+			view.offset = clampOffset(view.offset+leaderboardPageSize, total)
+		case "pgup":
+			view.offset = clampOffset(view.offset-leaderboardPageSize, total)
+		case "m", "M":
+			view = jumpToPlayer(p, view)
+		case "enter":
+			return Game
+		case "ctrl-c":
+			s.Close()
+			return nil
+		case ":":
+			shell.Write([]byte("\033[38;5;208m> \033[0m"))
+			_, nextScene, done := SafeReadInput(shell, s, p)
+			if done {
+				return nextScene
+			}
+		}
+	}
+}
+
+// clampOffset keeps the last page full instead of scrolling past the end.
+func clampOffset(offset, total int) int {
+	offset = min(offset, total-leaderboardPageSize)
+	return max(offset, 0)
+}
+
+// jumpToPlayer scrolls so the player's entry sits in the middle of the page.
+func jumpToPlayer(p *player.Player, view leaderboardView) leaderboardView {
+	if p.Guest {
+		view.notice = "Guests aren't on the leaderboard. Register to get a rank!"
+		return view
+	}
+
+	standing, err := stats.Default().Standing(view.query(), p.ID)
+	if err != nil {
+		log.Print(err)
+		view.notice = "Couldn't find your rank, try again later."
+		return view
+	}
+	if standing.Rank == 0 {
+		view.notice = fmt.Sprintf("You have no %s scores for %s yet.", modeLabel(string(view.mode), view.source.Name()), view.window)
+		return view
+	}
+
+	view.offset = clampOffset(standing.Rank-1-leaderboardPageSize/2, standing.Total)
+	return view
+}
+
+func renderLeaderboard(shell *term.Terminal, p *player.Player, view leaderboardView) int {
 	clearTerminal(shell)
 
 	shell.Write([]byte("\033[38;5;45m┌────────────────────────────────────────────────┐\033[0m\n"))
@@ -19,83 +202,115 @@ func Leaderboard(s glider.Session, p *player.Player) Scene {
 
 	shell.Write([]byte("\033[38;5;229mInstructions:\033[0m\n"))
 	shell.Write([]byte("\033[38;5;252m──────────────\033[0m\n"))
-	shell.Write([]byte("\033[38;5;248m• Press Enter to return to game\n"))
-	shell.Write([]byte("\033[38;5;248m• Type :q to quit or :help for more commands\033[0m\n\n"))
-
-	rows, err := data.DB.Query(`
-		SELECT 
-			 p.username,
-			 s.tp,
-			 s.accuracy,
-			 s.wpm,
-			 s.duration
-		FROM players p
-		JOIN scores s ON p.id = s.player_id
-		ORDER BY s.tp DESC
-		LIMIT 10;
-	`)
+	shell.Write([]byte("\033[38;5;248m• Use ←/→ or Tab to switch period\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Press r to switch between bests, averages and runs\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Press f to switch game mode (sentence, timed, word count)\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Press s to switch text (words, quotes, code), l to switch language\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Use j/k or PgUp/PgDn to scroll, m to jump to yourself\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Type :replay <run #> to watch a run\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Press Enter to return to game\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Press : to type a command (:q to quit, :help for more)\033[0m\n\n"))
+
+	// Period tabs
+	for i, w := range stats.Windows {
+		if i > 0 {
+			shell.Write([]byte("\033[38;5;240m │ \033[0m"))
+		}
+		if w == view.window {
+			shell.Write(fmt.Appendf(nil, "\033[1;38;5;16;48;5;51m %s \033[0m", w))
+		} else {
+			shell.Write(fmt.Appendf(nil, "\033[38;5;248m %s \033[0m", w))
+		}
+	}
+	shell.Write([]byte("\n"))
+
+	if language := view.wordLanguage(); language != "" {
+		shell.Write(fmt.Appendf(nil, "\033[38;5;248mMode: \033[1;38;5;51m%s\033[0m\033[38;5;248m · Text: \033[1;38;5;51m%s\033[0m\033[38;5;248m · Language: \033[1;38;5;51m%s\033[0m\n", view.mode, view.source.Name(), util.LanguageName(language)))
+	} else {
+		shell.Write(fmt.Appendf(nil, "\033[38;5;248mMode: \033[1;38;5;51m%s\033[0m\033[38;5;248m · Text: \033[1;38;5;51m%s\033[0m\n", view.mode, view.source.Name()))
+	}
+	switch view.ranking {
+	case stats.RankAverage:
+		shell.Write(fmt.Appendf(nil, "\033[38;5;248mRanking: \033[1;38;5;51m%s\033[0m\033[38;5;248m (last %d runs per player)\033[0m\n\n", view.ranking, stats.DefaultLastN))
+	default:
+		shell.Write(fmt.Appendf(nil, "\033[38;5;248mRanking: \033[1;38;5;51m%s\033[0m\n\n", view.ranking))
+	}
+
+	leaderboard, err := stats.Default().Top(view.query())
 	if err != nil {
 		shell.Write([]byte("Can't find info\n"))
 		log.Print(err)
-		return Game
+		return 0
 	}
-	defer rows.Close()
-
-	var leaderboard []player.LeaderboardEntry
 
-	for rows.Next() {
-		var entry player.LeaderboardEntry
-		if err := rows.Scan(&entry.PlayerName, &entry.Score.TP, &entry.Score.Accuracy, &entry.Score.WPM, &entry.Score.Duration); err != nil {
-			return Game
-		}
-		leaderboard = append(leaderboard, entry)
+	if len(leaderboard.Entries) == 0 {
+		shell.Write(fmt.Appendf(nil, "\033[38;5;248mNo %s scores for %s yet.\033[0m\n\n", modeLabel(string(view.mode), view.source.Name()), view.window))
+		return 0
 	}
 
-	if len(leaderboard) == 0 {
-		shell.Write([]byte("\033[38;5;248mNo leaderboard data available yet.\033[0m\n\n"))
-	} else {
-		shell.Write([]byte("\033[38;5;45m┌────────┬─────────────┬──────────┬───────┬───────────┬───────────┐\033[0m\n"))
-		shell.Write([]byte("\033[38;5;45m│ Rank   │ Player      │ Accuracy │ WPM   │ Time (s)  │ TP Score  │\033[0m\n"))
-		shell.Write([]byte("\033[38;5;45m├────────┼─────────────┼──────────┼───────┼───────────┼───────────┤\033[0m\n"))
-
-		for i, entry := range leaderboard {
-			rankColor := "\033[38;5;252m" // default grey
-			switch i {
-			case 0:
-				rankColor = "\033[38;5;226m" // gold
-			case 1:
-				rankColor = "\033[38;5;250m" // silver
-			case 2:
-				rankColor = "\033[38;5;172m" // bronze
-			}
+	shell.Write([]byte("\033[38;5;45m┌────────┬─────────────┬────────┬──────────┬───────┬───────────┬───────────┐\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m│ Rank   │ Player      │ Run #  │ Accuracy │ WPM   │ Time (s)  │ TP Score  │\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m├────────┼─────────────┼────────┼──────────┼───────┼───────────┼───────────┤\033[0m\n"))
 
-			playerName := entry.PlayerName
-			if len(playerName) > 11 {
-				playerName = playerName[:11]
-			}
+	for _, entry := range leaderboard.Entries {
+		rankColor := "\033[38;5;252m" // default grey
+		switch entry.Rank {
+		case 1:
+			rankColor = "\033[38;5;226m" // gold
+		case 2:
+			rankColor = "\033[38;5;250m" // silver
+		case 3:
+			rankColor = "\033[38;5;172m" // bronze
+		}
+		if !p.Guest && entry.PlayerID == p.ID {
+			rankColor = "\033[1;38;5;46m" // you
+		}
 
-			row := fmt.Sprintf(
-				"%s│ %-6d │ %-11s │ %8.2f │ %5.1f │ %9d │ %9.2f │\033[0m\n",
-				rankColor,
-				i+1,
-				playerName,
-				*entry.Score.Accuracy,
-				*entry.Score.WPM,
-				*entry.Score.Duration,
-				*entry.Score.TP,
-			)
-			shell.Write([]byte(row))
+		playerName := entry.PlayerName
+		if len(playerName) > 11 {
+			playerName = playerName[:11]
 		}
 
-		shell.Write([]byte("\033[38;5;45m└────────┴─────────────┴──────────┴───────┴───────────┴───────────┘\033[0m\n\n"))
+		row := fmt.Sprintf(
+			"%s│ %-6d │ %-11s │ %-6s │ %8.2f │ %5.1f │ %9d │ %9.2f │\033[0m\n",
+			rankColor,
+			entry.Rank,
+			playerName,
+			runNumber(entry.ScoreID),
+			entry.Accuracy,
+			entry.WPM,
+			entry.Duration,
+			entry.TP,
+		)
+		shell.Write([]byte(row))
 	}
 
-	shell.Write([]byte("\033[38;5;46mPress Enter to return to game...\033[0m\n"))
-	shell.Write([]byte("\033[38;5;208m> \033[0m"))
-	_, nextScene, done := SafeReadInput(shell, s, p)
-	if done {
-		return nextScene
+	shell.Write([]byte("\033[38;5;45m└────────┴─────────────┴────────┴──────────┴───────┴───────────┴───────────┘\033[0m\n"))
+
+	last := leaderboard.Offset + len(leaderboard.Entries)
+	shell.Write(fmt.Appendf(nil, "\033[38;5;248mShowing %d-%d of %d\033[0m\n\n", leaderboard.Offset+1, last, leaderboard.Total))
+
+	if view.notice != "" {
+		shell.Write(fmt.Appendf(nil, "\033[38;5;208m%s\033[0m\n\n", view.notice))
+	} else if !p.Guest {
+		renderStanding(shell, p, view)
+	}
+
+	return leaderboard.Total
+}
+
+// renderStanding prints the player's own rank and percentile on the board.
+func renderStanding(shell *term.Terminal, p *player.Player, view leaderboardView) {
+	standing, err := stats.Default().Standing(view.query(), p.ID)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	if standing.Rank == 0 {
+		return
 	}
 
-	return Game
+	shell.Write(fmt.Appendf(nil,
+		"\033[38;5;229mYour rank:\033[0m \033[1;38;5;46m#%d\033[0m\033[38;5;248m of %d · ahead of %.1f%% of the board\033[0m\n\n",
+		standing.Rank, standing.Total, standing.Percentile))
 }
@@ -20,10 +20,16 @@ var menuItems []MenuItem
 func init() {
 	menuItems = []MenuItem{
 		{"Single Player Game", "Practice typing with randomly generated sentences", Game},
+		{"Daily Challenge", "Everyone types the same text today, one ranked attempt", DailyChallenge},
+		{"Ghost Race", "Race a replay of your personal best run", GhostRace},
+		{"Focused Practice", "Drill the keys and letter pairs you miss most", FocusedPractice},
 		{"Multiplayer Lobby", "Chat with other players and challenge them", Lobby},
 		{"Duos Battle", "Real-time typing race with another player", Duos},
 		{"Leaderboard", "View top scores from all players", Leaderboard},
 		{"Your Scores", "View your personal typing history", ScoreList},
+		{"Your Weak Keys", "See which keys and letter pairs slow you down", WeakKeys},
+		{"Language", "Pick the language your words are in", Language},
+		{"SSH Keys", "Log in with your SSH key instead of a password", Keys},
 		{"Quit", "Exit the application", nil},
 	}
 }
@@ -118,31 +124,24 @@ func renderFullMenu(shell *term.Terminal, selectedIndex int) {
 }
 
 func readInput(s glider.Session) (string, error) {
-	buffer := make([]byte, 10)
-	n, err := s.Read(buffer)
+	input, err := readKey(s)
 	if err != nil {
 		return "", err
 	}
 
-	input := string(buffer[:n])
-
 	// Handle arrow keys and vim controls
 	switch {
-	case input == "\033[A": // Up arrow
+	case input == "up" || input == "k" || input == "K":
 		return "up", nil
-	case input == "\033[B": // Down arrow
+	case input == "down" || input == "j" || input == "J":
 		return "down", nil
-	case input == "\r" || input == "\n": // Enter
+	case input == "enter":
 		return "enter", nil
-	case input == "k" || input == "K":
-		return "up", nil
-	case input == "j" || input == "J":
-		return "down", nil
 	case input == ":":
 		return "command", nil
 	case len(input) == 1 && (input[0] >= 32 && input[0] <= 126): // Any other character are handles as a command input
 		return "command", nil
-	case input == "\003": // Ctrl+C
+	case input == "ctrl-c":
 		return "", fmt.Errorf("interrupted")
 	default:
 		// Ignore other inputs (function keys, etc.)
@@ -171,8 +170,10 @@ func SessionStart(s glider.Session) {
 		s.Close()
 		return
 	}
+	// Everything from here on reads the session through one reader
+	s = newSession(s)
 
-	p := player.GetOrCreatePlayer(s)
+	p := player.Login(s)
 	if p == nil {
 		log.Printf("Failed to create player for %s", s.User())
 		s.Close()
@@ -193,6 +194,8 @@ func SessionStart(s glider.Session) {
 	}()
 
 	log.Printf("Player %s connected", p.Name)
+	trackPlayer(p, true)
+	defer trackPlayer(p, false)
 
 	// Start with the main scene
 	currentScene := Main
@@ -0,0 +1,66 @@
+package scenes
+
+import (
+	"fmt"
+	"log"
+	"ssh-battle/player"
+	"sync"
+)
+
+// matchQueue pairs up players looking for a duos battle in the order they
+// arrived. Every pair gets a room of its own, so any number of battles can
+// run at once.
+type matchQueue struct {
+	mu      sync.Mutex
+	waiting []queuedPlayer
+	rooms   int
+}
+
+type queuedPlayer struct {
+	p     *player.Player
+	match chan *Room
+}
+
+var duosQueue = &matchQueue{}
+
+// join queues p and returns where its room is sent once an opponent turns up.
+// The player is already in the room by then, they only have to leave it.
+func (q *matchQueue) join(p *player.Player) <-chan *Room {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	match := make(chan *Room, 1)
+	if len(q.waiting) == 0 {
+		q.waiting = append(q.waiting, queuedPlayer{p, match})
+		return match
+	}
+
+	opponent := q.waiting[0]
+	q.waiting = q.waiting[1:]
+	q.rooms++
+	room := GetRoom(fmt.Sprintf("Duos-%d", q.rooms), newDuosBehavior())
+	// Joined here rather than by the players, so one leaving straight away
+	// can't close the room before the other is in
+	room.Join <- opponent.p
+	room.Join <- p
+	log.Printf("Matched %s and %s in room %s", opponent.p.Name, p.Name, room.ID)
+
+	opponent.match <- room
+	match <- room
+	return match
+}
+
+// leave takes p out of the queue. It returns false if p was already matched,
+// the room is then waiting on its channel.
+func (q *matchQueue) leave(p *player.Player) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, w := range q.waiting {
+		if w.p == p {
+			q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
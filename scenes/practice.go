@@ -0,0 +1,105 @@
+package scenes
+
+import (
+	"fmt"
+	"log"
+	"ssh-battle/player"
+	"ssh-battle/util"
+	"strings"
+
+	glider "github.com/gliderlabs/ssh"
+)
+
+// How many weak keys and slow letter pairs a practice sentence targets
+const practiceTargets = 5
+
+// FocusedPractice is single player with words picked to drill the player's
+// weak keys and slow letter pairs. Practice runs stay off the leaderboards.
+func FocusedPractice(s glider.Session, p *player.Player) Scene {
+	shell := p.Shell
+	clearTerminal(shell)
+
+	// Header
+	shell.Write([]byte("\033[38;5;45m┌────────────────────────────────────────────────┐\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m│ 🎯 \033[1;38;5;51mFocused Practice\033[0m\033[38;5;45m                          │\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m└────────────────────────────────────────────────┘\033[0m\n\n"))
+
+	// Instructions
+	shell.Write([]byte("\033[38;5;229mInstructions:\033[0m\n"))
+	shell.Write([]byte("\033[38;5;252m──────────────\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Words are picked to drill the keys you miss most\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Practice runs are saved but don't count for the leaderboard\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Type :q to quit or :help for more commands (Esc while typing)\033[0m\n\n"))
+
+	a, err := p.Analytics()
+	if err != nil {
+		log.Println("DB error loading key stats:", err)
+	}
+	focus := a.Focus(practiceTargets, minKeyPresses, minBigramSamples)
+
+	shell.Write([]byte("\033[38;5;229mFocus:\033[0m\n"))
+	shell.Write([]byte("\033[38;5;252m──────\033[0m\n"))
+	// Weights change with every run, so practice text can't be generated
+	// again and its seed isn't kept
+	gen := util.NewGenerator(util.NewSeed(), util.TextOptions{Language: p.Language, Difficulty: p.Difficulty})
+	var sentence string
+	if focus.Empty() {
+		shell.Write([]byte("\033[38;5;248mNo weak keys recorded yet, here's a regular sentence to start with.\033[0m\n\n"))
+		sentence, err = gen.Sentence()
+	} else {
+		if len(focus.Keys) > 0 {
+			keys := make([]string, len(focus.Keys))
+			for i, k := range focus.Keys {
+				keys[i] = string(k.Key)
+			}
+			shell.Write(fmt.Appendf(nil, "\033[38;5;248mKeys: \033[1;38;5;196m%s\033[0m\n", strings.Join(keys, " ")))
+		}
+		if len(focus.Bigrams) > 0 {
+			bigrams := make([]string, len(focus.Bigrams))
+			for i, b := range focus.Bigrams {
+				bigrams[i] = b.Bigram
+			}
+			shell.Write(fmt.Appendf(nil, "\033[38;5;248mLetter pairs: \033[1;38;5;214m%s\033[0m\n", strings.Join(bigrams, " ")))
+		}
+		shell.Write([]byte("\n"))
+		sentence, err = gen.WeightedSentence(focus.Weight)
+	}
+	if err != nil {
+		return noText(s, p, err)
+	}
+
+	shell.Write([]byte("\033[38;5;46mPress Enter when you're ready...\033[0m\n"))
+	shell.Write([]byte("\033[38;5;208m> \033[0m"))
+	_, nextScene, done := SafeReadInput(shell, s, p)
+	if done {
+		return nextScene
+	}
+
+	shell.Write([]byte("\n"))
+	keys, nextScene, done := newTypingWidget(shell, sentence, 0).Run(s, p)
+	if done {
+		return nextScene
+	}
+
+	score := player.ScoreCalculation(sentence, keys, p.Difficulty)
+	score.Practice = true
+	score.Language = p.Language
+	if !p.Guest {
+		if err := player.SaveScore(p.ID, &score); err != nil {
+			log.Println("DB error saving score:", err)
+		}
+	}
+	p.Scores = append(p.Scores, score)
+
+	writeResults(shell, score, termWidth(p))
+	shell.Write([]byte("\033[38;5;248m🎯 Practice run, it won't show on the leaderboard.\033[0m\n\n"))
+
+	shell.Write([]byte("\033[38;5;46mPress Enter for another practice sentence...\033[0m\n"))
+	shell.Write([]byte("\033[38;5;208m> \033[0m"))
+	_, nextScene, done = SafeReadInput(shell, s, p)
+	if done {
+		return nextScene
+	}
+
+	return FocusedPractice
+}
@@ -0,0 +1,39 @@
+package scenes
+
+import (
+	"fmt"
+	"log"
+	"ssh-battle/player"
+	"ssh-battle/util"
+
+	glider "github.com/gliderlabs/ssh"
+)
+
+// Reload reads the word lists again without restarting the server. Only
+// admins may run it, sending the server SIGHUP does the same.
+func Reload(s glider.Session, p *player.Player) Scene {
+	shell := p.Shell
+	clearTerminal(shell)
+
+	shell.Write([]byte("\033[38;5;45m┌────────────────────────────────────────────────┐\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m│ 🔄 \033[1;38;5;51mReload Words\033[0m\033[38;5;45m                              │\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m└────────────────────────────────────────────────┘\033[0m\n\n"))
+
+	if !p.IsAdmin() {
+		shell.Write([]byte("\033[38;5;196m❌ Only admins can reload the word lists.\033[0m\n\n"))
+	} else if c, err := util.ReloadCorpus(gameConfig); err != nil {
+		log.Printf("%s couldn't reload the words: %v", p.Name, err)
+		shell.Write(fmt.Appendf(nil, "\033[38;5;196m❌ Reload failed, the old words are still in use: %v\033[0m\n\n", err))
+	} else {
+		log.Printf("%s reloaded the words", p.Name)
+		shell.Write(fmt.Appendf(nil, "\033[38;5;46m✅ Loaded %d words in %d languages\033[0m\n\n", c.Size(), len(c.Languages())))
+	}
+
+	shell.Write([]byte("\033[38;5;46mPress Enter to return to main menu...\033[0m\n"))
+	shell.Write([]byte("\033[38;5;208m> \033[0m"))
+	_, nextScene, done := SafeReadInput(shell, s, p)
+	if done {
+		return nextScene
+	}
+	return Main
+}
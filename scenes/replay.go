@@ -0,0 +1,132 @@
+package scenes
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"ssh-battle/player"
+	"strconv"
+	"strings"
+	"time"
+
+	glider "github.com/gliderlabs/ssh"
+)
+
+// replayArgs handles ":replay <run #> [1x|2x]".
+func replayArgs(args []string) (Scene, error) {
+	id, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	if err != nil || id <= 0 {
+		return nil, fmt.Errorf("%q isn't a run number", args[0])
+	}
+
+	speed := 1
+	if len(args) > 1 {
+		switch strings.ToLower(args[1]) {
+		case "1x", "1":
+			speed = 1
+		case "2x", "2":
+			speed = 2
+		default:
+			return nil, fmt.Errorf("unknown speed %q", args[1])
+		}
+	}
+
+	replay, err := player.LoadReplay(id)
+	if err == player.ErrReplayNotFound {
+		return nil, fmt.Errorf("run #%d has no replay", id)
+	}
+	if err != nil {
+		log.Println("DB error loading replay:", err)
+		return nil, errors.New("can't load that replay right now")
+	}
+
+	return func(s glider.Session, p *player.Player) Scene {
+		return showReplay(s, p, replay, speed)
+	}, nil
+}
+
+func showReplay(s glider.Session, p *player.Player, replay player.Replay, speed int) Scene {
+	shell := p.Shell
+	clearTerminal(shell)
+
+	shell.Write([]byte("\033[38;5;45m┌────────────────────────────────────────────────┐\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m│ 🎬 \033[1;38;5;51mReplay\033[0m\033[38;5;45m                                    │\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m└────────────────────────────────────────────────┘\033[0m\n\n"))
+
+	shell.Write(fmt.Appendf(nil, "\033[38;5;248mRun \033[1;38;5;51m#%d\033[0m\033[38;5;248m by \033[1;38;5;51m%s\033[0m\033[38;5;248m · %s\033[0m\n\n",
+		replay.ScoreID, replay.PlayerName, replay.CreatedAt.UTC().Format("2006-01-02 15:04 UTC")))
+
+	keys := replay.Keys.Keys
+	w := newRunWidget(shell, replay.Mode, replay.Sentence, termWidth(p))
+	setHint := func(next int) {
+		if next >= len(keys) {
+			w.hint = fmt.Sprintf("Finished in %.1fs · r to watch again · Enter to go back", replay.Keys.Elapsed.Seconds())
+		} else {
+			w.hint = fmt.Sprintf("Speed %dx · Space to switch · r to restart · Enter to go back · Esc for commands", speed)
+		}
+	}
+
+	in := s.(*session)
+
+	w.mu.Lock()
+	w.start = time.Now()
+	setHint(0)
+	shell.Write([]byte("\0337"))
+	w.render()
+	w.mu.Unlock()
+
+	// pos is how far into the run playback is
+	next, pos := 0, time.Duration(0)
+	for {
+		var due <-chan time.Time
+		if next < len(keys) {
+			due = time.After((keys[next].At - pos) / time.Duration(speed))
+		}
+		mark := time.Now()
+
+		select {
+		case <-due:
+			pos = keys[next].At
+			w.mu.Lock()
+			for next < len(keys) && keys[next].At <= pos {
+				w.press(keys[next])
+				next++
+			}
+			setHint(next)
+			w.render()
+			w.mu.Unlock()
+
+		case chunk, ok := <-in.keys():
+			if !ok || chunk.err != nil {
+				return nil
+			}
+			if next < len(keys) {
+				pos += time.Since(mark) * time.Duration(speed)
+			}
+
+			switch chunk.input {
+			case "\003":
+				s.Close()
+				return nil
+			case "\r", "\n", "q":
+				return Main
+			case " ":
+				speed = 3 - speed
+			case "r", "R":
+				next, pos = 0, 0
+				w.mu.Lock()
+				w.typed, w.keys = nil, nil
+				w.mu.Unlock()
+			case "\033":
+				if nextScene, done := w.commandPrompt(s, p); done {
+					return nextScene
+				}
+			}
+
+			w.mu.Lock()
+			setHint(next)
+			w.render()
+			w.mu.Unlock()
+		}
+	}
+}
@@ -36,6 +36,14 @@ type ResettableBehavior interface {
 	Reset()
 }
 
+// MatchBehavior is implemented by rooms that run matches the server should
+// let finish before it shuts down.
+type MatchBehavior interface {
+	RoomBehavior
+	MatchInProgress() bool
+	SavePartialResults(r *Room)
+}
+
 var defaultRoomManager = &RoomManager{
 	rooms: make(map[string]*Room),
 }
@@ -52,7 +60,8 @@ func (r *Room) Run() {
 		case p := <-r.Leave:
 			r.mu.Lock()
 			delete(r.Players, p.Name)
-			empty := len(r.Players) == 0
+			// A player still waiting to be let in keeps the room open
+			empty := len(r.Players) == 0 && len(r.Join) == 0
 			r.mu.Unlock()
 			r.Behavior.OnLeave(r, p)
 
@@ -96,3 +105,15 @@ func GetRoom(id string, behavior RoomBehavior) *Room {
 
 	return room
 }
+
+// Rooms returns a snapshot of all active rooms.
+func (m *RoomManager) Rooms() []*Room {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rooms := make([]*Room, 0, len(m.rooms))
+	for _, room := range m.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
@@ -0,0 +1,100 @@
+package scenes
+
+import (
+	"io"
+	"unicode/utf8"
+
+	glider "github.com/gliderlabs/ssh"
+)
+
+// keyChunk is one read from the session, which can hold several keys when
+// the player types fast or pastes.
+type keyChunk struct {
+	input string
+	err   error
+}
+
+// session is a player's SSH session with a single goroutine doing all the
+// reading. Scenes take turns with the input: the terminal, readKey and the
+// typing widget all read through it, so one that stops waiting for keys, like
+// a widget whose time ran out, never leaves a read behind to swallow the next
+// scene's first key.
+type session struct {
+	glider.Session
+	chunks chan keyChunk
+	// Input a Read didn't have room for, handed to whoever reads next
+	ready chan keyChunk
+}
+
+func newSession(s glider.Session) *session {
+	in := &session{Session: s, chunks: make(chan keyChunk), ready: make(chan keyChunk, 1)}
+	go in.pump()
+	return in
+}
+
+func (s *session) pump() {
+	defer close(s.chunks)
+	buffer := make([]byte, 256)
+	var partial []byte
+	for {
+		n, err := s.Session.Read(buffer)
+		input := append(partial, buffer[:n]...)
+		// Characters outside ASCII are several bytes and can be split across
+		// reads, hold on to the start until the rest arrives
+		partial = nil
+		if cut := incompleteRune(input); cut > 0 && err == nil {
+			partial = append([]byte(nil), input[len(input)-cut:]...)
+			input = input[:len(input)-cut]
+		}
+		if len(input) == 0 && err == nil {
+			continue
+		}
+
+		select {
+		case s.chunks <- keyChunk{string(input), err}:
+		case <-s.Context().Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// keys is the channel the next chunk of input comes in on, for scenes that
+// wait on keys and timers at once. It is closed after a chunk with an error.
+// Only the scene's goroutine reads input, and it takes a chunk from keys or
+// Read, never both at once.
+func (s *session) keys() <-chan keyChunk {
+	if len(s.ready) > 0 {
+		return s.ready
+	}
+	return s.chunks
+}
+
+func (s *session) Read(b []byte) (int, error) {
+	chunk, ok := <-s.keys()
+	if !ok {
+		return 0, io.EOF
+	}
+	n := copy(b, chunk.input)
+	if n < len(chunk.input) {
+		s.ready <- keyChunk{input: chunk.input[n:]}
+		return n, nil
+	}
+	return n, chunk.err
+}
+
+// incompleteRune returns how many bytes at the end of b are the start of a
+// character that hasn't been read completely yet.
+func incompleteRune(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if utf8.FullRune(b[i:]) {
+				return 0
+			}
+			return len(b) - i
+		}
+	}
+	return 0
+}
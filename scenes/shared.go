@@ -1,7 +1,11 @@
 package scenes
 
 import (
+	"errors"
+	"log"
+	"ssh-battle/config"
 	"ssh-battle/player"
+	"ssh-battle/util"
 
 	glider "github.com/gliderlabs/ssh"
 	"golang.org/x/term"
@@ -10,6 +14,72 @@ import (
 // Scene function type for switching between scenes
 type Scene func(glider.Session, *player.Player) Scene
 
+var gameConfig = config.Default().Game
+
+// Configure sets the game settings used by the scenes. Call it before the server starts.
+func Configure(cfg config.Game) {
+	gameConfig = cfg
+}
+
+// readKey reads one keypress. Special keys are named ("up", "down", "left",
+// "right", "pgup", "pgdown", "enter", "tab", "backspace", "esc", "ctrl-c"),
+// anything else is returned as typed.
+func readKey(s glider.Session) (string, error) {
+	buffer := make([]byte, 10)
+	n, err := s.Read(buffer)
+	if err != nil {
+		return "", err
+	}
+
+	switch input := string(buffer[:n]); input {
+	case "\033[A":
+		return "up", nil
+	case "\033[B":
+		return "down", nil
+	case "\033[C":
+		return "right", nil
+	case "\033[D":
+		return "left", nil
+	case "\033[5~":
+		return "pgup", nil
+	case "\033[6~":
+		return "pgdown", nil
+	case "\r", "\n":
+		return "enter", nil
+	case "\t":
+		return "tab", nil
+	case "\177", "\b":
+		return "backspace", nil
+	case "\033":
+		return "esc", nil
+	case "\003":
+		return "ctrl-c", nil
+	default:
+		return input, nil
+	}
+}
+
 func clearTerminal(shell *term.Terminal) {
 	shell.Write([]byte("\033[2J\033[H")) // ANSI escape to clear screen and move cursor home
 }
+
+// noText tells the player there's nothing to type because generating the text
+// failed, e.g. the word lists didn't load or the player's language was
+// removed, and waits for Enter to go back to the menu.
+func noText(s glider.Session, p *player.Player, err error) Scene {
+	log.Println("Error generating text:", err)
+	shell := p.Shell
+
+	if errors.Is(err, util.ErrNoLanguage) {
+		shell.Write([]byte("\033[38;5;196m❌ The language picked has no words anymore, pick another one to play.\033[0m\n\n"))
+	} else {
+		shell.Write([]byte("\033[38;5;196m❌ Couldn't come up with anything to type right now, try again later.\033[0m\n\n"))
+	}
+	shell.Write([]byte("\033[38;5;46mPress Enter to return to the menu...\033[0m\n"))
+	shell.Write([]byte("\033[38;5;208m> \033[0m"))
+	_, nextScene, done := SafeReadInput(shell, s, p)
+	if done {
+		return nextScene
+	}
+	return Main
+}
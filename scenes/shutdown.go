@@ -0,0 +1,82 @@
+package scenes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"ssh-battle/player"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var shuttingDown atomic.Bool
+
+var activeMu sync.Mutex
+var activePlayers = make(map[*player.Player]struct{})
+
+func trackPlayer(p *player.Player, active bool) {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	if active {
+		activePlayers[p] = struct{}{}
+	} else {
+		delete(activePlayers, p)
+	}
+}
+
+// Shutdown tells every room the server is going down, stops new matches from
+// starting and waits for running ones to finish. If ctx ends first, players
+// still typing get their partial scores saved instead.
+func Shutdown(ctx context.Context) {
+	shuttingDown.Store(true)
+
+	notice := "\033[1;38;5;196m🔧 Server restarting soon, finish up!\033[0m"
+	if deadline, ok := ctx.Deadline(); ok {
+		notice = fmt.Sprintf("\033[1;38;5;196m🔧 Server restarting in %.0f seconds, finish up!\033[0m", time.Until(deadline).Seconds())
+	}
+	for _, room := range defaultRoomManager.Rooms() {
+		select {
+		case room.Broadcast <- RoomMessage{"Server", notice}:
+		default:
+			log.Printf("Couldn't deliver shutdown notice to room %s", room.ID)
+		}
+	}
+
+	waitForMatches(ctx)
+
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	for p := range activePlayers {
+		p.Session.Write([]byte("\r\n\033[1;38;5;196m🔧 Server is restarting, see you soon!\033[0m\r\n"))
+	}
+}
+
+func waitForMatches(ctx context.Context) {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		running := 0
+		for _, room := range defaultRoomManager.Rooms() {
+			if match, ok := room.Behavior.(MatchBehavior); ok && match.MatchInProgress() {
+				running++
+			}
+		}
+		if running == 0 {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Shutdown grace period over with %d matches running, saving partial scores", running)
+			for _, room := range defaultRoomManager.Rooms() {
+				if match, ok := room.Behavior.(MatchBehavior); ok && match.MatchInProgress() {
+					match.SavePartialResults(room)
+				}
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,414 @@
+package scenes
+
+import (
+	"fmt"
+	"slices"
+	"ssh-battle/player"
+	"ssh-battle/util"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	glider "github.com/gliderlabs/ssh"
+	"golang.org/x/term"
+)
+
+// typingWidget reads an attempt at a sentence key by key, colouring each
+// character as it is typed instead of waiting for Enter.
+type typingWidget struct {
+	shell  *term.Terminal
+	target []rune
+	// 0 for no time limit
+	limit time.Duration
+	// Controls shown under the sentence
+	hint string
+	// A recorded run of the same sentence raced alongside the player, or nil
+	ghost *player.KeystrokeLog
+	// Adds words to the end of the target as the player gets close to it, so
+	// timed runs never run out of text. Enter doesn't submit with a feed.
+	feed func() string
+	// Lines of the target shown at once, word wrapped to width. 0 shows the
+	// whole target and lets the terminal wrap it.
+	lines int
+	width int
+	// The target has line breaks, like code, so Enter types one instead of
+	// submitting until the end of the target
+	multiline bool
+
+	mu       sync.Mutex
+	typed    []rune
+	keys     []player.Keystroke
+	start    time.Time
+	notices  []string
+	paused   bool
+	timedOut bool
+}
+
+func newTypingWidget(shell *term.Terminal, sentence string, limit time.Duration) *typingWidget {
+	w := &typingWidget{
+		shell:  shell,
+		target: []rune(sentence),
+		limit:  limit,
+		hint:   "Enter to submit · Backspace to correct · Esc for commands",
+	}
+	if strings.ContainsRune(sentence, '\n') {
+		w.multiline = true
+		w.hint = "Enter for a new line · Enter at the end or Ctrl+D to submit · Backspace to correct · Esc for commands"
+	}
+	return w
+}
+
+// Run shows the sentence and records keystrokes until the player presses
+// Enter, types the whole sentence correctly or runs out of time. Esc opens
+// the command prompt; if a command leaves the scene, done is true and
+// nextScene is where to go.
+func (w *typingWidget) Run(s glider.Session, p *player.Player) (player.KeystrokeLog, Scene, bool) {
+	in := s.(*session)
+
+	w.mu.Lock()
+	w.start = time.Now()
+	w.shell.Write([]byte("\0337")) // Save cursor, every redraw starts here
+	w.render()
+	w.mu.Unlock()
+
+	var deadline <-chan time.Time
+	if w.limit > 0 {
+		deadline = time.After(w.limit)
+	}
+
+	// Redraw for the countdown, or often enough for the ghost to move smoothly
+	var tick <-chan time.Time
+	if w.limit > 0 || w.ghost != nil {
+		interval := time.Second
+		if w.ghost != nil {
+			interval = 100 * time.Millisecond
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-deadline:
+			w.mu.Lock()
+			w.timedOut = true
+			w.mu.Unlock()
+			return w.log(w.limit), nil, false
+
+		case <-tick:
+			w.mu.Lock()
+			w.render()
+			w.mu.Unlock()
+
+		case chunk, ok := <-in.keys():
+			if !ok || chunk.err != nil {
+				return w.log(time.Since(w.start)), nil, true
+			}
+
+			switch {
+			case chunk.input == "\003": // Ctrl+C
+				s.Close()
+				return w.log(time.Since(w.start)), nil, true
+
+			case chunk.input == "\033":
+				nextScene, done := w.commandPrompt(s, p)
+				if done {
+					return w.log(time.Since(w.start)), nextScene, true
+				}
+
+			case strings.HasPrefix(chunk.input, "\033"):
+				// Arrow keys and other escape sequences don't move the cursor
+
+			default:
+				if w.handle(chunk.input) {
+					return w.log(time.Since(w.start)), nil, false
+				}
+			}
+		}
+	}
+}
+
+// TimedOut reports whether Run ended because the time limit ran out.
+func (w *typingWidget) TimedOut() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.timedOut
+}
+
+// Notify shows a message under the sentence without disturbing the input.
+// It is safe to call from other goroutines.
+func (w *typingWidget) Notify(msg string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.notices = append(w.notices, msg)
+	if len(w.notices) > 3 {
+		w.notices = w.notices[len(w.notices)-3:]
+	}
+	if !w.start.IsZero() && !w.paused {
+		w.render()
+	}
+}
+
+// handle applies typed input and reports whether the attempt is finished.
+func (w *typingWidget) handle(input string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	at := time.Since(w.start)
+	// Accents sent as a letter and a combining mark count as one character
+	for _, r := range util.Compose(input) {
+		switch r {
+		case '\r', '\n':
+			// In code Enter is a line break anywhere before the end, so a
+			// mistake earlier on the line doesn't submit the attempt
+			if pos := len(w.typed); w.multiline && pos < len(w.target) {
+				w.press(player.Keystroke{At: at, Rune: '\n'})
+				// The next line's indentation is typed for you, like an editor
+				// would, and logged as not typed so it doesn't count as speed
+				if w.target[pos] == '\n' {
+					for pos++; pos < len(w.target) && w.target[pos] == ' '; pos++ {
+						w.press(player.Keystroke{At: at, Rune: ' ', Auto: true})
+					}
+				}
+				continue
+			}
+			// Fed runs only end when the time is up
+			if w.feed == nil {
+				return true
+			}
+		case '\004': // Ctrl+D submits wherever the cursor is
+			if w.feed == nil {
+				return true
+			}
+		case '\177', '\b':
+			w.press(player.Keystroke{At: at, Rune: player.Backspace})
+		case '\027': // Ctrl+W deletes back to the start of the word
+			for len(w.typed) > 0 && w.typed[len(w.typed)-1] == ' ' {
+				w.press(player.Keystroke{At: at, Rune: player.Backspace})
+			}
+			for len(w.typed) > 0 && w.typed[len(w.typed)-1] != ' ' {
+				w.press(player.Keystroke{At: at, Rune: player.Backspace})
+			}
+		default:
+			if !unicode.IsControl(r) {
+				w.press(player.Keystroke{At: at, Rune: r})
+			}
+		}
+	}
+
+	w.render()
+	return w.complete()
+}
+
+// press applies one keystroke and logs it. Backspaces with nothing to delete
+// aren't logged. Callers hold w.mu.
+func (w *typingWidget) press(k player.Keystroke) {
+	if k.Rune != player.Backspace {
+		w.typed = append(w.typed, k.Rune)
+		// Keep a couple of lines of text ahead of the cursor
+		for w.feed != nil && len(w.target)-len(w.typed) < 2*max(w.width, 80) {
+			more := w.feed()
+			if more == "" {
+				// Nothing to feed right now, try again on the next key
+				break
+			}
+			w.target = append(w.target, []rune(" "+more)...)
+		}
+	} else if len(w.typed) > 0 {
+		w.typed = w.typed[:len(w.typed)-1]
+	} else {
+		return
+	}
+	w.keys = append(w.keys, k)
+}
+
+// complete reports whether the whole sentence is typed correctly. Fed
+// targets never are. Callers hold w.mu.
+func (w *typingWidget) complete() bool {
+	return w.feed == nil && string(w.typed) == string(w.target)
+}
+
+// Target returns the text the attempt was made against, including any words
+// fed in while typing.
+func (w *typingWidget) Target() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return string(w.target)
+}
+
+// commandPrompt lets the player run a command mid attempt. The clock keeps
+// running.
+func (w *typingWidget) commandPrompt(s glider.Session, p *player.Player) (Scene, bool) {
+	w.mu.Lock()
+	w.paused = true
+	w.mu.Unlock()
+
+	w.shell.Write([]byte("\n\033[38;5;248mCommand (Enter to continue):\033[0m\n"))
+	w.shell.Write([]byte("\033[38;5;208m> \033[0m"))
+	_, nextScene, done := SafeReadInput(w.shell, s, p)
+	if done {
+		return nextScene, true
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.paused = false
+	w.shell.Write([]byte("\n\0337"))
+	w.render()
+	return nil, false
+}
+
+func (w *typingWidget) log(elapsed time.Duration) player.KeystrokeLog {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return player.KeystrokeLog{Keys: slices.Clone(w.keys), Elapsed: elapsed}
+}
+
+// Keystrokes returns what has been typed so far while Run is still going.
+// It is safe to call from other goroutines.
+func (w *typingWidget) Keystrokes() player.KeystrokeLog {
+	w.mu.Lock()
+	start := w.start
+	w.mu.Unlock()
+
+	var elapsed time.Duration
+	if !start.IsZero() {
+		elapsed = time.Since(start)
+	}
+	return w.log(elapsed)
+}
+
+// ghostPos replays the ghost up to now and returns its cursor position, and
+// whether it has finished. Callers hold w.mu.
+func (w *typingWidget) ghostPos() (int, bool) {
+	elapsed := time.Since(w.start)
+	pos := 0
+	for _, k := range w.ghost.Keys {
+		if k.At > elapsed {
+			break
+		}
+		if k.Rune != player.Backspace {
+			pos++
+		} else if pos > 0 {
+			pos--
+		}
+	}
+	return pos, elapsed >= w.ghost.Elapsed
+}
+
+// render redraws the sentence from the saved cursor. Callers hold w.mu.
+func (w *typingWidget) render() {
+	var b strings.Builder
+	b.WriteString("\0338\033[J") // Restore cursor, clear everything below
+
+	ghostAt, ghostDone := -1, false
+	if w.ghost != nil {
+		ghostAt, ghostDone = w.ghostPos()
+	}
+
+	start, end, breaks := 0, len(w.target), map[int]bool{}
+	if w.lines > 0 {
+		start, end, breaks = w.window()
+	}
+
+	for i := start; i < end; i++ {
+		r := w.target[i]
+		// Line breaks show as ↵ so they can be coloured like any other key
+		if r == '\n' {
+			r = '↵'
+			breaks[i] = true
+		}
+		switch {
+		case i == len(w.typed):
+			fmt.Fprintf(&b, "\033[7;38;5;252m%c\033[0m", r) // cursor
+		case i == ghostAt:
+			fmt.Fprintf(&b, "\033[48;5;54;38;5;252m%c\033[0m", r) // ghost cursor
+		case i > len(w.typed):
+			fmt.Fprintf(&b, "\033[38;5;244m%c\033[0m", r)
+		case w.typed[i] == w.target[i]:
+			fmt.Fprintf(&b, "\033[38;5;46m%c\033[0m", r)
+		case r == ' ':
+			b.WriteString("\033[48;5;52m \033[0m") // missed space
+		default:
+			fmt.Fprintf(&b, "\033[38;5;196m%c\033[0m", r)
+		}
+		if breaks[i] {
+			b.WriteString("\n")
+		}
+	}
+
+	// Anything typed past the end of the sentence
+	if end == len(w.target) && len(w.typed) > len(w.target) {
+		fmt.Fprintf(&b, "\033[38;5;196;48;5;52m%s\033[0m", string(w.typed[len(w.target):]))
+	}
+	if end == len(w.target) && len(w.typed) >= len(w.target) {
+		b.WriteString("\033[7m \033[0m")
+	}
+	b.WriteString("\n\n")
+
+	if w.ghost != nil {
+		if ghostDone {
+			fmt.Fprintf(&b, "\033[38;5;141m👻 Ghost finished in %.1fs\033[0m\n", w.ghost.Elapsed.Seconds())
+		} else {
+			fmt.Fprintf(&b, "\033[38;5;141m👻 Ghost %d/%d\033[0m\033[38;5;248m · You %d/%d\033[0m\n", ghostAt, len(w.target), len(w.typed), len(w.target))
+		}
+	}
+	if w.limit > 0 {
+		left := max(w.limit-time.Since(w.start), 0)
+		color := "248"
+		if left <= 10*time.Second {
+			color = "196"
+		}
+		fmt.Fprintf(&b, "\033[38;5;%sm⏰ %.0f seconds left\033[0m\n", color, left.Seconds())
+	}
+	b.WriteString("\033[38;5;240m" + w.hint + "\033[0m\n")
+
+	for _, msg := range w.notices {
+		b.WriteString("\033[38;5;252m" + msg + "\033[0m\n")
+	}
+
+	w.shell.Write([]byte(b.String()))
+}
+
+// window word wraps the target to w.width and returns the range of it to
+// show, w.lines lines from the one before the cursor, and the indexes after
+// which a line ends. Callers hold w.mu.
+func (w *typingWidget) window() (start, end int, breaks map[int]bool) {
+	// One column spare so a full line doesn't wrap on its own
+	width := max(w.width-1, 20)
+
+	lineStarts := []int{0}
+	lastSpace := -1
+	for i, r := range w.target {
+		if r == ' ' {
+			lastSpace = i
+		}
+		lineStart := lineStarts[len(lineStarts)-1]
+		// Words longer than a line are left for the terminal to wrap
+		if i-lineStart >= width && lastSpace >= lineStart {
+			lineStarts = append(lineStarts, lastSpace+1)
+		}
+	}
+
+	cursor := min(len(w.typed), len(w.target)-1)
+	line := 0
+	for line+1 < len(lineStarts) && lineStarts[line+1] <= cursor {
+		line++
+	}
+
+	first := max(line-1, 0)
+	last := min(first+w.lines, len(lineStarts))
+	start, end = lineStarts[first], len(w.target)
+	if last < len(lineStarts) {
+		end = lineStarts[last]
+	}
+
+	breaks = map[int]bool{}
+	for _, s := range lineStarts[first+1 : last] {
+		breaks[s-1] = true
+	}
+	return start, end, breaks
+}
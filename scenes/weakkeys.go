@@ -0,0 +1,172 @@
+package scenes
+
+import (
+	"fmt"
+	"log"
+	"ssh-battle/player"
+	"strings"
+
+	glider "github.com/gliderlabs/ssh"
+	"golang.org/x/term"
+)
+
+// keyboardRows is the QWERTY layout drawn by the heatmap, each row indented
+// a little further like a real keyboard.
+var keyboardRows = []string{"1234567890-=", "qwertyuiop[]", "asdfghjkl;'", "zxcvbnm,./"}
+
+// shiftedKeys maps characters typed with shift to the key they're on.
+var shiftedKeys = map[rune]rune{
+	'!': '1', '@': '2', '#': '3', '$': '4', '%': '5', '^': '6', '&': '7', '*': '8', '(': '9', ')': '0',
+	'_': '-', '+': '=', '{': '[', '}': ']', ':': ';', '"': '\'', '<': ',', '>': '.', '?': '/',
+}
+
+// heatLevels colours a key by its error rate, first level whose limit it's under.
+var heatLevels = []struct {
+	limit float64
+	bg    int
+	label string
+}{
+	{2, 28, "<2%"},
+	{5, 106, "<5%"},
+	{10, 178, "<10%"},
+	{20, 166, "<20%"},
+	{101, 160, "20%+"},
+}
+
+// Keys need this many presses before they're listed as weak
+const minKeyPresses = 5
+
+// Letter pairs need this many samples before they're listed as slow
+const minBigramSamples = 3
+
+// WeakKeys shows which keys the player misses most and which letter pairs
+// slow them down, with a keyboard heatmap of error rates.
+func WeakKeys(s glider.Session, p *player.Player) Scene {
+	shell := p.Shell
+	clearTerminal(shell)
+
+	// Header
+	shell.Write([]byte("\033[38;5;45m┌────────────────────────────────────────────────┐\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m│ ⌨️  \033[1;38;5;51mYour Weak Keys\033[0m\033[38;5;45m                            │\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m└────────────────────────────────────────────────┘\033[0m\n\n"))
+
+	// Instructions
+	shell.Write([]byte("\033[38;5;229mInstructions:\033[0m\n"))
+	shell.Write([]byte("\033[38;5;252m──────────────\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Every run you finish adds to these stats\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Drill them in Focused Practice (\033[1;38;5;51m:practice\033[0m\033[38;5;248m)\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Press Enter to return to the menu\033[0m\n"))
+	shell.Write([]byte("\033[38;5;248m• Type :q to quit or :help for more commands\033[0m\n\n"))
+
+	a, err := p.Analytics()
+	if err != nil {
+		log.Println("DB error loading key stats:", err)
+		shell.Write([]byte("\033[38;5;196mCan't load your key stats right now.\033[0m\n\n"))
+	}
+
+	if len(a.Keys) == 0 {
+		shell.Write([]byte("\033[38;5;248mNo key stats yet. Play a game to start!\033[0m\n\n"))
+	} else {
+		shell.Write([]byte("\033[38;5;229mError Rate by Key:\033[0m\n"))
+		shell.Write([]byte("\033[38;5;252m──────────────────\033[0m\n"))
+		writeHeatmap(shell, a)
+		writeMissedKeys(shell, a.WeakKeys(minKeyPresses))
+		writeSlowBigrams(shell, a.SlowBigrams(minBigramSamples))
+	}
+
+	// Footer prompt
+	shell.Write([]byte("\033[38;5;46mPress Enter to return to the menu...\033[0m\n"))
+	shell.Write([]byte("\033[38;5;208m> \033[0m"))
+	_, nextScene, done := SafeReadInput(shell, s, p)
+	if done {
+		return nextScene
+	}
+
+	return Main
+}
+
+func writeHeatmap(shell *term.Terminal, a player.Analytics) {
+	// Shifted characters count towards the key they're typed on
+	keys := map[rune]player.KeyStat{}
+	for r, k := range a.Keys {
+		if base, ok := shiftedKeys[r]; ok {
+			r = base
+		}
+		stat := keys[r]
+		stat.Presses += k.Presses
+		stat.Errors += k.Errors
+		keys[r] = stat
+	}
+
+	for i, row := range keyboardRows {
+		line := strings.Repeat(" ", i*2)
+		for _, r := range row {
+			line += heatKey(string(r), keys[r]) + " "
+		}
+		shell.Write([]byte(line + "\n"))
+	}
+	shell.Write([]byte(strings.Repeat(" ", 12) + heatKey(strings.Repeat(" ", 11)+"space"+strings.Repeat(" ", 11), keys[' ']) + "\n\n"))
+
+	legend := "\033[48;5;236;38;5;244m no data \033[0m "
+	for _, l := range heatLevels {
+		legend += fmt.Sprintf("\033[48;5;%d;1;38;5;231m %s \033[0m ", l.bg, l.label)
+	}
+	shell.Write([]byte(legend + "\n\n"))
+}
+
+// heatKey draws one key with its background coloured by error rate.
+func heatKey(label string, k player.KeyStat) string {
+	if k.Presses == 0 {
+		return "\033[48;5;236;38;5;244m " + label + " \033[0m"
+	}
+	rate := k.ErrorRate()
+	bg := heatLevels[len(heatLevels)-1].bg
+	for _, l := range heatLevels {
+		if rate < l.limit {
+			bg = l.bg
+			break
+		}
+	}
+	return fmt.Sprintf("\033[48;5;%d;1;38;5;231m %s \033[0m", bg, label)
+}
+
+func writeMissedKeys(shell *term.Terminal, keys []player.KeyStat) {
+	shell.Write([]byte("\033[38;5;229mMost Missed Keys:\033[0m\n"))
+	shell.Write([]byte("\033[38;5;252m─────────────────\033[0m\n"))
+	if len(keys) == 0 {
+		shell.Write(fmt.Appendf(nil, "\033[38;5;248mNo key pressed %d times yet.\033[0m\n\n", minKeyPresses))
+		return
+	}
+
+	shell.Write([]byte("\033[38;5;45m┌───────┬─────────┬────────┬────────────┐\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m│ Key   │ Presses │ Errors │ Error Rate │\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m├───────┼─────────┼────────┼────────────┤\033[0m\n"))
+	for _, k := range keys[:min(len(keys), 5)] {
+		label := string(k.Key)
+		switch k.Key {
+		case ' ':
+			label = "space"
+		case '\n':
+			label = "enter"
+		}
+		shell.Write(fmt.Appendf(nil, "\033[38;5;252m│ %-5s │ %7d │ %6d │ %9.1f%% │\033[0m\n", label, k.Presses, k.Errors, k.ErrorRate()))
+	}
+	shell.Write([]byte("\033[38;5;45m└───────┴─────────┴────────┴────────────┘\033[0m\n\n"))
+}
+
+func writeSlowBigrams(shell *term.Terminal, bigrams []player.BigramStat) {
+	shell.Write([]byte("\033[38;5;229mSlowest Letter Pairs:\033[0m\n"))
+	shell.Write([]byte("\033[38;5;252m─────────────────────\033[0m\n"))
+	if len(bigrams) == 0 {
+		shell.Write(fmt.Appendf(nil, "\033[38;5;248mNo letter pair typed %d times yet.\033[0m\n\n", minBigramSamples))
+		return
+	}
+
+	shell.Write([]byte("\033[38;5;45m┌───────┬─────────┬──────────┐\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m│ Pair  │ Samples │ Avg (ms) │\033[0m\n"))
+	shell.Write([]byte("\033[38;5;45m├───────┼─────────┼──────────┤\033[0m\n"))
+	for _, b := range bigrams[:min(len(bigrams), 5)] {
+		shell.Write(fmt.Appendf(nil, "\033[38;5;252m│ %-5s │ %7d │ %8d │\033[0m\n", b.Bigram, b.Samples, b.Average().Milliseconds()))
+	}
+	shell.Write([]byte("\033[38;5;45m└───────┴─────────┴──────────┘\033[0m\n\n"))
+}
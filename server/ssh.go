@@ -1,7 +1,10 @@
 package server
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"ssh-battle/config"
 	"ssh-battle/keys"
 	"ssh-battle/player"
 	"ssh-battle/scenes"
@@ -14,16 +17,38 @@ import (
 var loggedInUsers = make(map[string]bool)
 var loggedInMu sync.Mutex
 
-func StartServer() {
-	hostKey, err := keys.LoadHostKey("host_key.pem")
+// StartServer serves SSH until ctx is cancelled, then stops accepting
+// connections, gives running matches cfg.ShutdownGrace to finish and closes
+// all sessions.
+func StartServer(ctx context.Context, cfg config.Server) error {
+	hostKey, err := keys.LoadHostKey(cfg.HostKeyPath)
 	if err != nil {
-		log.Fatal("Failed to load host key:", err)
+		return fmt.Errorf("loading host key: %w", err)
 	}
 
 	server := &glider.Server{
-		Addr: ":2222",
+		Addr: cfg.Addr,
 		PasswordHandler: func(ctx glider.Context, password string) bool {
-			return player.CheckPassword(ctx.User(), password)
+			if player.CheckPassword(ctx.User(), password) {
+				ctx.SetValue(player.ContextKeyAuth, player.AuthPassword)
+				return true
+			}
+
+			// Unregistered names get in so they can register (or play as a guest)
+			registered, err := player.IsRegistered(ctx.User())
+			if err != nil || registered {
+				return false
+			}
+			ctx.SetValue(player.ContextKeyAuth, player.AuthPending)
+			return true
+		},
+		PublicKeyHandler: func(ctx glider.Context, key glider.PublicKey) bool {
+			// Unknown keys fall through to password auth
+			if !player.CheckPublicKey(ctx.User(), key) {
+				return false
+			}
+			ctx.SetValue(player.ContextKeyAuth, player.AuthPublicKey)
+			return true
 		},
 		Handler: func(s glider.Session) {
 			username := strings.ToLower(s.User())
@@ -31,7 +56,7 @@ func StartServer() {
 			loggedInMu.Lock()
 			if strings.ToLower(s.User()) == "root" {
 				loggedInMu.Unlock()
-				s.Write([]byte("Can't login as root to avoid bots from scanning this session. Try running something like \"ssh Username@quinver.dev -p 2222\"...\n"))
+				s.Write([]byte(cfg.RootBanner + "\n"))
 				s.Close()
 				return
 			}
@@ -57,8 +82,27 @@ func StartServer() {
 		HostSigners: []glider.Signer{hostKey},
 	}
 
-	log.Println("Listening on port 2222...")
-	if err := server.ListenAndServe(); err != nil {
-		log.Fatal(err)
+	log.Printf("Listening on %s...", cfg.Addr)
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
 	}
+
+	log.Printf("Shutting down, giving running matches %s to finish...", cfg.ShutdownGrace)
+	graceCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownGrace)
+	defer cancel()
+
+	// Closes the listener right away, then waits on sessions we end below
+	go server.Shutdown(graceCtx)
+
+	scenes.Shutdown(graceCtx)
+
+	log.Println("Closing remaining sessions")
+	return server.Close()
 }
@@ -0,0 +1,117 @@
+package stats
+
+import (
+	"strings"
+	"time"
+)
+
+// Daily returns day's ranked daily challenge attempts, best TP first. Each
+// player has at most one, so there is no paging.
+func (b *Board) Daily(day string) ([]Entry, error) {
+	rows, err := b.db.Query(b.dialect.Rebind(`
+		SELECT s.id, s.player_id, p.username,
+			COALESCE(s.accuracy, 0), COALESCE(s.wpm, 0), COALESCE(s.tp, 0), COALESCE(s.duration, 0),
+			s.created_at, s.mode, s.source
+		FROM daily_attempts a
+		JOIN scores s ON s.id = a.score_id
+		JOIN players p ON p.id = a.player_id
+		WHERE a.day = ?
+		ORDER BY s.tp DESC, s.id`), day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		e := Entry{Rank: len(entries) + 1, Runs: 1}
+		err := rows.Scan(&e.ScoreID, &e.PlayerID, &e.PlayerName, &e.Accuracy, &e.WPM, &e.TP, &e.Duration, &e.CreatedAt, &e.Mode, &e.Source)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Streak counts days in a row a player finished their ranked daily challenge.
+type Streak struct {
+	// Up to today, or up to yesterday while today's is still open
+	Current int
+	Best    int
+}
+
+// Streak works out playerID's daily challenge streaks as of today, a
+// YYYY-MM-DD date.
+func (b *Board) Streak(playerID int, today string) (Streak, error) {
+	streaks, err := b.Streaks([]int{playerID}, today)
+	return streaks[playerID], err
+}
+
+// Streaks works out the daily challenge streaks of several players at once,
+// like everyone on a board. Players who never finished one are left out.
+func (b *Board) Streaks(playerIDs []int, today string) (map[int]Streak, error) {
+	streaks := make(map[int]Streak)
+	if len(playerIDs) == 0 {
+		return streaks, nil
+	}
+	now, err := time.Parse(time.DateOnly, today)
+	if err != nil {
+		return streaks, err
+	}
+
+	args := make([]any, len(playerIDs))
+	for i, id := range playerIDs {
+		args[i] = id
+	}
+	rows, err := b.db.Query(b.dialect.Rebind(`
+		SELECT player_id, day FROM daily_attempts
+		WHERE player_id IN (?`+strings.Repeat(", ?", len(playerIDs)-1)+`) AND score_id IS NOT NULL
+		ORDER BY player_id, day DESC`), args...)
+	if err != nil {
+		return streaks, err
+	}
+	defer rows.Close()
+
+	days := make(map[int][]time.Time)
+	for rows.Next() {
+		var playerID int
+		var day string
+		if err := rows.Scan(&playerID, &day); err != nil {
+			return streaks, err
+		}
+		t, err := time.Parse(time.DateOnly, day)
+		if err != nil {
+			return streaks, err
+		}
+		days[playerID] = append(days[playerID], t)
+	}
+	if err := rows.Err(); err != nil {
+		return streaks, err
+	}
+
+	for id, played := range days {
+		streaks[id] = streak(played, now)
+	}
+	return streaks, nil
+}
+
+// streak counts the runs of consecutive days in days, newest first.
+func streak(days []time.Time, now time.Time) Streak {
+	var st Streak
+	// A streak breaks where the days are more than a day apart
+	run := 1
+	current := now.Sub(days[0]) <= 24*time.Hour
+	for i := 1; i <= len(days); i++ {
+		if i < len(days) && days[i-1].Sub(days[i]) == 24*time.Hour {
+			run++
+			continue
+		}
+		if current {
+			st.Current, current = run, false
+		}
+		st.Best = max(st.Best, run)
+		run = 1
+	}
+	return st
+}
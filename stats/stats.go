@@ -0,0 +1,353 @@
+// Package stats holds the read-side queries behind the leaderboards and score
+// lists, so scenes only have to render the results.
+package stats
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"ssh-battle/data"
+)
+
+// Ranking decides what a leaderboard row is.
+type Ranking int
+
+const (
+	// One row per player with their personal best run
+	RankBest Ranking = iota
+	// One row per player averaging their last Query.LastN runs
+	RankAverage
+	// Every run is its own row, so one player can fill the board
+	RankRuns
+)
+
+var Rankings = []Ranking{RankBest, RankAverage, RankRuns}
+
+// DefaultLastN is how many recent runs RankAverage uses when Query.LastN is 0.
+const DefaultLastN = 10
+
+func (r Ranking) String() string {
+	switch r {
+	case RankAverage:
+		return "Recent Average"
+	case RankRuns:
+		return "Top Runs"
+	default:
+		return "Personal Bests"
+	}
+}
+
+// ParseRanking accepts names like "best", "avg" and "runs".
+func ParseRanking(s string) (Ranking, bool) {
+	switch strings.ToLower(s) {
+	case "best", "bests", "players", "unique":
+		return RankBest, true
+	case "avg", "average", "recent":
+		return RankAverage, true
+	case "runs", "all-runs", "raw":
+		return RankRuns, true
+	}
+	return RankBest, false
+}
+
+type Query struct {
+	Ranking Ranking
+	// Runs per player averaged by RankAverage, DefaultLastN if 0
+	LastN int
+
+	// Only scores of this player, 0 for everyone
+	PlayerID int
+	// Count practice runs too, the leaderboards leave them out
+	IncludePractice bool
+	// Only scores of this single player mode, "" for every mode
+	Mode string
+	// Only scores typed from this text source, "" for every source
+	Source string
+	// Only scores typed in this language, "" for every language
+	Language string
+	// Only scores created in [Since, Until), zero values leave that side open
+	Since time.Time
+	Until time.Time
+
+	Limit  int
+	Offset int
+}
+
+type Entry struct {
+	Rank int
+	// 0 for RankAverage rows, which don't come from a single run
+	ScoreID    int
+	PlayerID   int
+	PlayerName string
+	Accuracy   float64
+	WPM        float64
+	TP         float64
+	Duration   int
+	CreatedAt  time.Time
+	Mode       string
+	Source     string
+	// Runs behind a RankAverage row, 1 otherwise
+	Runs int
+}
+
+type Page struct {
+	Entries []Entry
+	// Number of entries matching the query over all pages
+	Total  int
+	Offset int
+	Limit  int
+}
+
+type Board struct {
+	db      *sql.DB
+	dialect data.Dialect
+}
+
+func New(db *sql.DB, dialect data.Dialect) *Board {
+	return &Board{db: db, dialect: dialect}
+}
+
+// Default returns a Board on the repository opened by data.InitDB.
+func Default() *Board {
+	return New(data.Repo.DB(), data.Repo.Dialect())
+}
+
+// where builds the WHERE clause shared by the page and count queries.
+func (q Query) where() (string, []any) {
+	var conds []string
+	var args []any
+
+	if !q.IncludePractice {
+		conds = append(conds, "s.ranked = ?")
+		args = append(args, true)
+	}
+	if q.Mode != "" {
+		conds = append(conds, "s.mode = ?")
+		args = append(args, q.Mode)
+	}
+	if q.Source != "" {
+		conds = append(conds, "s.source = ?")
+		args = append(args, q.Source)
+	}
+	if q.Language != "" {
+		conds = append(conds, "s.language = ?")
+		args = append(args, q.Language)
+	}
+	if q.PlayerID != 0 {
+		conds = append(conds, "s.player_id = ?")
+		args = append(args, q.PlayerID)
+	}
+	if !q.Since.IsZero() {
+		conds = append(conds, "s.created_at >= ?")
+		args = append(args, q.Since.UTC())
+	}
+	if !q.Until.IsZero() {
+		conds = append(conds, "s.created_at < ?")
+		args = append(args, q.Until.UTC())
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+// ranked returns q's leaderboard rows, unordered and unpaged, as a subquery
+// with the columns id, player_id, username, accuracy, wpm, tp, duration,
+// created_at, mode, source and runs. Rows order by tp DESC, id, player_id.
+func (q Query) ranked() (string, []any) {
+	where, args := q.where()
+
+	switch q.Ranking {
+	case RankRuns:
+		return `
+			SELECT s.id, s.player_id, p.username,
+				COALESCE(s.accuracy, 0) AS accuracy, COALESCE(s.wpm, 0) AS wpm,
+				COALESCE(s.tp, 0) AS tp, COALESCE(s.duration, 0) AS duration, s.created_at,
+				s.mode, s.source, 1 AS runs
+			FROM scores s
+			JOIN players p ON p.id = s.player_id
+			` + where, args
+	case RankAverage:
+		lastN := q.LastN
+		if lastN <= 0 {
+			lastN = DefaultLastN
+		}
+		// Averages aren't a single run, so there is no score id or date. The
+		// mode and source are only meaningful when the query picks them.
+		return `
+			SELECT 0 AS id, player_id, username,
+				AVG(accuracy) AS accuracy, AVG(wpm) AS wpm, AVG(tp) AS tp,
+				CAST(AVG(duration) AS INTEGER) AS duration, NULL AS created_at,
+				MIN(mode) AS mode, MIN(source) AS source, COUNT(*) AS runs
+			FROM (
+				SELECT s.player_id, p.username,
+					COALESCE(s.accuracy, 0) AS accuracy, COALESCE(s.wpm, 0) AS wpm,
+					COALESCE(s.tp, 0) AS tp, COALESCE(s.duration, 0) AS duration, s.mode, s.source,
+					ROW_NUMBER() OVER (PARTITION BY s.player_id ORDER BY s.created_at DESC, s.id DESC) AS recent
+				FROM scores s
+				JOIN players p ON p.id = s.player_id
+				` + where + `
+			) recent_runs
+			WHERE recent <= ?
+			GROUP BY player_id, username`, append(args, lastN)
+	default:
+		return `
+			SELECT id, player_id, username, accuracy, wpm, tp, duration, created_at, mode, source, 1 AS runs
+			FROM (
+				SELECT s.id, s.player_id, p.username,
+					COALESCE(s.accuracy, 0) AS accuracy, COALESCE(s.wpm, 0) AS wpm,
+					COALESCE(s.tp, 0) AS tp, COALESCE(s.duration, 0) AS duration, s.created_at, s.mode, s.source,
+					ROW_NUMBER() OVER (PARTITION BY s.player_id ORDER BY s.tp DESC, s.id) AS best
+				FROM scores s
+				JOIN players p ON p.id = s.player_id
+				` + where + `
+			) player_runs
+			WHERE best = 1`, args
+	}
+}
+
+// Top returns one page of q's leaderboard, best TP first.
+func (b *Board) Top(q Query) (Page, error) {
+	page := Page{Offset: q.Offset, Limit: q.Limit}
+	ranked, args := q.ranked()
+
+	err := b.db.QueryRow(b.dialect.Rebind("SELECT COUNT(*) FROM ("+ranked+") board"), args...).Scan(&page.Total)
+	if err != nil {
+		return page, err
+	}
+
+	rows, err := b.db.Query(b.dialect.Rebind(`
+		SELECT id, player_id, username, accuracy, wpm, tp, duration, created_at, mode, source, runs
+		FROM (`+ranked+`) board
+		ORDER BY tp DESC, id, player_id
+		LIMIT ? OFFSET ?`), append(args, q.Limit, q.Offset)...)
+	if err != nil {
+		return page, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e := Entry{Rank: q.Offset + len(page.Entries) + 1}
+		var createdAt sql.NullTime
+		err := rows.Scan(&e.ScoreID, &e.PlayerID, &e.PlayerName, &e.Accuracy, &e.WPM, &e.TP, &e.Duration, &createdAt, &e.Mode, &e.Source, &e.Runs)
+		if err != nil {
+			return page, err
+		}
+		e.CreatedAt = createdAt.Time
+		page.Entries = append(page.Entries, e)
+	}
+	return page, rows.Err()
+}
+
+// Standing is where one player sits on a leaderboard.
+type Standing struct {
+	// 1 based, 0 if the player has no entry on the board
+	Rank  int
+	Total int
+	// Share of the other entries ranked below the player, 0-100
+	Percentile float64
+}
+
+// Standing finds playerID's best placed entry on q's leaderboard. q's
+// PlayerID, Limit and Offset are ignored.
+func (b *Board) Standing(q Query, playerID int) (Standing, error) {
+	var st Standing
+	q.PlayerID = 0
+	ranked, args := q.ranked()
+
+	err := b.db.QueryRow(b.dialect.Rebind(`
+		SELECT COALESCE(MIN(CASE WHEN player_id = ? THEN position END), 0), COUNT(*)
+		FROM (
+			SELECT player_id, ROW_NUMBER() OVER (ORDER BY tp DESC, id, player_id) AS position
+			FROM (`+ranked+`) board
+		) positions`), append([]any{playerID}, args...)...).Scan(&st.Rank, &st.Total)
+	if err != nil || st.Rank == 0 {
+		return st, err
+	}
+
+	st.Percentile = 100
+	if st.Total > 1 {
+		st.Percentile = 100 * float64(st.Total-st.Rank) / float64(st.Total-1)
+	}
+	return st, nil
+}
+
+// TopN returns the n best players overall by personal best.
+func (b *Board) TopN(n int) ([]Entry, error) {
+	page, err := b.Top(Query{Limit: n})
+	return page.Entries, err
+}
+
+// PlayerTop returns the n best runs of one player.
+func (b *Board) PlayerTop(playerID, n int) ([]Entry, error) {
+	page, err := b.Top(Query{Ranking: RankRuns, PlayerID: playerID, Limit: n})
+	return page.Entries, err
+}
+
+// TopSince returns the n best players by personal best in [since, until).
+func (b *Board) TopSince(since, until time.Time, n int) ([]Entry, error) {
+	page, err := b.Top(Query{Since: since, Until: until, Limit: n})
+	return page.Entries, err
+}
+
+// Window is a leaderboard period. Periods are calendar based in UTC, weeks
+// start on Monday.
+type Window int
+
+const (
+	Daily Window = iota
+	Weekly
+	Monthly
+	AllTime
+)
+
+var Windows = []Window{Daily, Weekly, Monthly, AllTime}
+
+func (w Window) String() string {
+	switch w {
+	case Daily:
+		return "Today"
+	case Weekly:
+		return "This Week"
+	case Monthly:
+		return "This Month"
+	default:
+		return "All Time"
+	}
+}
+
+// Since returns the start of the period that now falls in, zero for AllTime.
+func (w Window) Since(now time.Time) time.Time {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch w {
+	case Daily:
+		return day
+	case Weekly:
+		// Go weeks start on Sunday
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case Monthly:
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Time{}
+	}
+}
+
+// ParseWindow accepts names like "day", "week", "month" and "all".
+func ParseWindow(s string) (Window, bool) {
+	switch strings.ToLower(s) {
+	case "day", "daily", "today":
+		return Daily, true
+	case "week", "weekly":
+		return Weekly, true
+	case "month", "monthly":
+		return Monthly, true
+	case "all", "alltime", "all-time", "ever":
+		return AllTime, true
+	}
+	return AllTime, false
+}
@@ -0,0 +1,341 @@
+package stats
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"ssh-battle/config"
+	"ssh-battle/data"
+)
+
+// now is a Wednesday, so this week started two days ago on Monday the 9th.
+var now = time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+
+// openBoard returns a Board on a migrated SQLite database in a temp dir.
+func openBoard(t *testing.T) (*Board, data.Repository) {
+	t.Helper()
+	repo, err := data.Open(config.Database{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "game.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	if _, err := repo.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	return New(repo.DB(), repo.Dialect()), repo
+}
+
+func addPlayer(t *testing.T, repo data.Repository, name string) int {
+	t.Helper()
+	if err := repo.Players().Register(name, "hash"); err != nil {
+		t.Fatal(err)
+	}
+	p, err := repo.Players().Get(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.ID
+}
+
+type run struct {
+	player string
+	tp     float64
+	ago    time.Duration
+	mode   string
+	source string
+	ranked bool
+}
+
+// seedBoard adds alice, bob, carol and dave with the runs below, score ids
+// 1-8 in order.
+func seedBoard(t *testing.T, repo data.Repository) map[string]int {
+	t.Helper()
+	day := 24 * time.Hour
+	runs := []run{
+		{"alice", 50, time.Hour, "sentence", "words", true},   // 1
+		{"alice", 80, 10 * day, "sentence", "words", true},    // 2, March 1st
+		{"bob", 70, 2 * time.Hour, "sentence", "words", true}, // 3
+		{"bob", 75, 2 * day, "sentence", "words", true},       // 4, Monday
+		{"carol", 70, 30 * time.Minute, "30s", "words", true}, // 5
+		{"carol", 90, time.Hour, "sentence", "quotes", true},  // 6
+		{"dave", 100, time.Hour, "sentence", "words", false},  // 7, practice
+		{"dave", 40, 40 * day, "sentence", "words", true},     // 8, last month
+	}
+
+	ids := map[string]int{}
+	for i, r := range runs {
+		if _, ok := ids[r.player]; !ok {
+			ids[r.player] = addPlayer(t, repo, r.player)
+		}
+		id, err := repo.Scores().Add(data.ScoreRecord{
+			PlayerID: ids[r.player], TP: r.tp, CreatedAt: now.Add(-r.ago),
+			Ranked: r.ranked, Mode: r.mode, Source: r.source, Language: "en",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != i+1 {
+			t.Fatalf("run %d saved as score %d", i+1, id)
+		}
+	}
+	return ids
+}
+
+func TestTop(t *testing.T) {
+	board, repo := openBoard(t)
+	seedBoard(t, repo)
+
+	tests := []struct {
+		name  string
+		q     Query
+		want  []int // score ids, best first
+		total int
+	}{
+		{"personal bests", Query{Limit: 10}, []int{6, 2, 4, 8}, 4},
+		{"practice included", Query{IncludePractice: true, Limit: 10}, []int{7, 6, 2, 4}, 4},
+		{"today", Query{Since: Daily.Since(now), Limit: 10}, []int{6, 3, 1}, 3},
+		{"this week", Query{Since: Weekly.Since(now), Limit: 10}, []int{6, 4, 1}, 3},
+		{"this month", Query{Since: Monthly.Since(now), Limit: 10}, []int{6, 2, 4}, 3},
+		{"before today", Query{Until: Daily.Since(now), Limit: 10}, []int{2, 4, 8}, 3},
+		{"mode", Query{Mode: "30s", Limit: 10}, []int{5}, 1},
+		{"source", Query{Source: "quotes", Limit: 10}, []int{6}, 1},
+		{"mode and source", Query{Mode: "sentence", Source: "words", Limit: 10}, []int{2, 4, 8}, 3},
+		{"nothing matches", Query{Mode: "60s", Limit: 10}, nil, 0},
+		{"first page", Query{Limit: 2}, []int{6, 2}, 4},
+		{"second page", Query{Limit: 2, Offset: 2}, []int{4, 8}, 4},
+		{"past the end", Query{Limit: 2, Offset: 4}, nil, 4},
+		// Bob's 3 and Carol's 5 are both 70 TP, the older score ranks first
+		{"runs with a tie", Query{Ranking: RankRuns, Limit: 10}, []int{6, 2, 4, 3, 5, 1, 8}, 7},
+		{"runs page over a tie", Query{Ranking: RankRuns, Limit: 2, Offset: 3}, []int{3, 5}, 7},
+		{"one player's runs", Query{Ranking: RankRuns, PlayerID: 2, Limit: 10}, []int{4, 3}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := board.Top(tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int
+			for i, e := range page.Entries {
+				got = append(got, e.ScoreID)
+				if e.Rank != tt.q.Offset+i+1 {
+					t.Errorf("entry %d has rank %d, want %d", i, e.Rank, tt.q.Offset+i+1)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("scores %v, want %v", got, tt.want)
+			}
+			if page.Total != tt.total {
+				t.Errorf("total %d, want %d", page.Total, tt.total)
+			}
+		})
+	}
+}
+
+func TestTopAverage(t *testing.T) {
+	board, repo := openBoard(t)
+	seedBoard(t, repo)
+
+	page, err := board.Top(Query{Ranking: RankAverage, LastN: 2, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Averages of the last two ranked runs, Dave's practice run left out
+	want := []string{"carol 80.0 over 2", "bob 72.5 over 2", "alice 65.0 over 2", "dave 40.0 over 1"}
+	var got []string
+	for _, e := range page.Entries {
+		got = append(got, fmt.Sprintf("%s %.1f over %d", e.PlayerName, e.TP, e.Runs))
+		if e.ScoreID != 0 || !e.CreatedAt.IsZero() {
+			t.Errorf("%s's average has score %d from %v", e.PlayerName, e.ScoreID, e.CreatedAt)
+		}
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("averages %q, want %q", got, want)
+	}
+
+	// Only the newest run counts with LastN 1
+	page, err = board.Top(Query{Ranking: RankAverage, LastN: 1, Limit: 1, Offset: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 1 || page.Entries[0].PlayerName != "alice" || page.Entries[0].TP != 50 || page.Total != 4 {
+		t.Errorf("third newest run average = %+v of %d, want alice's 50", page.Entries, page.Total)
+	}
+}
+
+func TestStanding(t *testing.T) {
+	board, repo := openBoard(t)
+	ids := seedBoard(t, repo)
+
+	tests := []struct {
+		name   string
+		q      Query
+		player string
+		want   Standing
+	}{
+		{"first", Query{}, "carol", Standing{Rank: 1, Total: 4, Percentile: 100}},
+		{"middle", Query{}, "bob", Standing{Rank: 3, Total: 4, Percentile: 100.0 / 3}},
+		{"last", Query{}, "dave", Standing{Rank: 4, Total: 4, Percentile: 0}},
+		{"only entry", Query{Mode: "30s"}, "carol", Standing{Rank: 1, Total: 1, Percentile: 100}},
+		{"not on the board", Query{Since: Daily.Since(now)}, "dave", Standing{Rank: 0, Total: 3}},
+		// Bob's best run is third, his tied 70 with Carol comes later
+		{"best of a player's runs", Query{Ranking: RankRuns}, "bob", Standing{Rank: 3, Total: 7, Percentile: 100 * 4.0 / 6}},
+		{"tied run ranks after the older one", Query{Ranking: RankRuns, Source: "words", Since: Daily.Since(now)}, "carol", Standing{Rank: 2, Total: 3, Percentile: 50}},
+		{"paging ignored", Query{Limit: 1, Offset: 3, PlayerID: ids["carol"]}, "bob", Standing{Rank: 3, Total: 4, Percentile: 100.0 / 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := board.Standing(tt.q, ids[tt.player])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Rank != tt.want.Rank || got.Total != tt.want.Total || fmt.Sprintf("%.4f", got.Percentile) != fmt.Sprintf("%.4f", tt.want.Percentile) {
+				t.Errorf("Standing = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowSince(t *testing.T) {
+	tests := []struct {
+		window Window
+		now    time.Time
+		want   time.Time
+	}{
+		{Daily, now, time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{Weekly, now, time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)},
+		// Sunday still belongs to the week that started on Monday
+		{Weekly, time.Date(2026, 3, 15, 23, 0, 0, 0, time.UTC), time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)},
+		{Weekly, time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)},
+		{Monthly, now, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{AllTime, now, time.Time{}},
+		// Periods are in UTC whatever zone now is in
+		{Daily, time.Date(2026, 3, 11, 1, 0, 0, 0, time.FixedZone("CET", 3600)), time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{Daily, time.Date(2026, 3, 11, 0, 30, 0, 0, time.FixedZone("CET", 3600)), time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := tt.window.Since(tt.now); !got.Equal(tt.want) {
+			t.Errorf("%s.Since(%v) = %v, want %v", tt.window, tt.now, got, tt.want)
+		}
+	}
+}
+
+// playDaily finishes player's ranked daily attempt on each day with a score
+// of tp.
+func playDaily(t *testing.T, repo data.Repository, playerID int, tp float64, days ...string) {
+	t.Helper()
+	for _, day := range days {
+		created, err := time.Parse(time.DateOnly, day)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := repo.Scores().Add(data.ScoreRecord{PlayerID: playerID, TP: tp, CreatedAt: created, Ranked: true, Mode: "daily", Source: "words", Language: "en"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.Daily().Start(playerID, day); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.Daily().Finish(playerID, day, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestStreak(t *testing.T) {
+	board, repo := openBoard(t)
+	today := "2026-03-11"
+
+	tests := []struct {
+		name string
+		days []string
+		want Streak
+	}{
+		{"never played", nil, Streak{}},
+		{"today only", []string{"2026-03-11"}, Streak{Current: 1, Best: 1}},
+		{"today still open", []string{"2026-03-10", "2026-03-09"}, Streak{Current: 2, Best: 2}},
+		{"broken yesterday", []string{"2026-03-09", "2026-03-08"}, Streak{Current: 0, Best: 2}},
+		{"longer best before", []string{"2026-03-11", "2026-03-10", "2026-03-07", "2026-03-06", "2026-03-05", "2026-03-04"}, Streak{Current: 2, Best: 4}},
+		{"over a month end", []string{"2026-03-01", "2026-02-28", "2026-02-27"}, Streak{Current: 0, Best: 3}},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := addPlayer(t, repo, fmt.Sprintf("player%d", i))
+			playDaily(t, repo, id, 10, tt.days...)
+
+			got, err := board.Streak(id, today)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Streak = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// A started attempt that was never finished doesn't count
+	id := addPlayer(t, repo, "quitter")
+	playDaily(t, repo, id, 10, "2026-03-10")
+	if _, err := repo.Daily().Start(id, today); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := board.Streak(id, today); err != nil || got != (Streak{Current: 1, Best: 1}) {
+		t.Errorf("Streak with an unfinished attempt today = %+v, %v", got, err)
+	}
+}
+
+func TestDailyBoard(t *testing.T) {
+	board, repo := openBoard(t)
+	alice := addPlayer(t, repo, "alice")
+	bob := addPlayer(t, repo, "bob")
+	carol := addPlayer(t, repo, "carol")
+
+	playDaily(t, repo, alice, 30, "2026-03-11")
+	playDaily(t, repo, bob, 50, "2026-03-11")
+	playDaily(t, repo, carol, 90, "2026-03-10")
+	// Carol started today's but never finished it
+	if _, err := repo.Daily().Start(carol, "2026-03-11"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := board.Daily("2026-03-11")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, fmt.Sprintf("%d %s", e.Rank, e.PlayerName))
+	}
+	if want := []string{"1 bob", "2 alice"}; !slices.Equal(got, want) {
+		t.Errorf("Daily = %q, want %q", got, want)
+	}
+}
+
+func TestStreaks(t *testing.T) {
+	board, repo := openBoard(t)
+	alice := addPlayer(t, repo, "alice")
+	bob := addPlayer(t, repo, "bob")
+	carol := addPlayer(t, repo, "carol")
+
+	playDaily(t, repo, alice, 10, "2026-03-11", "2026-03-10", "2026-03-09")
+	playDaily(t, repo, bob, 10, "2026-03-10", "2026-03-08", "2026-03-07")
+	playDaily(t, repo, carol, 10, "2026-03-11")
+
+	// Carol isn't asked for, Dave (id 99) never played
+	got, err := board.Streaks([]int{alice, bob, 99}, "2026-03-11")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]Streak{alice: {Current: 3, Best: 3}, bob: {Current: 1, Best: 2}}
+	if len(got) != len(want) || got[alice] != want[alice] || got[bob] != want[bob] {
+		t.Errorf("Streaks = %+v, want %+v", got, want)
+	}
+
+	if got, err := board.Streaks(nil, "2026-03-11"); err != nil || len(got) != 0 {
+		t.Errorf("Streaks of nobody = %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,11 @@
+package util
+
+import "golang.org/x/text/unicode/norm"
+
+// Compose folds letters followed by combining accents, which some terminals
+// and input methods send instead of the precomposed character, into their
+// NFC form, so "ä" compares equal to "ä". Marks with no precomposed
+// form are kept as typed.
+func Compose(s string) string {
+	return norm.NFC.String(s)
+}
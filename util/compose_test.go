@@ -0,0 +1,30 @@
+package util
+
+import "testing"
+
+func TestCompose(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"hello", "hello"},
+		{"a\u0308", "ä"},
+		{"Gru\u0308ße", "Grüße"},
+		{"cafe\u0301", "café"},
+		{"ä", "ä"},
+		// Letters a hand written table easily misses
+		{"s\u030c", "š"},
+		{"o\u030b", "ő"},
+		{"z\u0307", "ż"},
+		{"a\u0328", "ą"},
+		// Nothing to compose with, kept as typed
+		{"q\u0308", "q\u0308"},
+		{"\u0308", "\u0308"},
+		// Keys the typing widget handles have to come through untouched
+		{"ab\r\177\027", "ab\r\177\027"},
+	}
+	for _, tt := range tests {
+		if got := Compose(tt.in); got != tt.want {
+			t.Errorf("Compose(%+q) = %+q, want %+q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"ssh-battle/config"
+	"ssh-battle/data"
+	"sync/atomic"
+)
+
+// ErrNoWords is returned when there is nothing to build a sentence from,
+// because the corpus isn't loaded or the words table is empty.
+var ErrNoWords = errors.New("no words available")
+
+// ErrNoLanguage is returned for a language without words, e.g. a pack that
+// was removed after players picked it.
+var ErrNoLanguage = errors.New("no words in that language")
+
+// Corpus is every language's word list, held in memory so generating a
+// sentence doesn't hit the database. A loaded Corpus is never modified,
+// reloading swaps in a new one, so any number of sessions can read it at once.
+type Corpus struct {
+	words map[string][]string
+}
+
+var corpus atomic.Pointer[Corpus]
+
+// LoadCorpus reads the words of every language from the database and makes
+// them the current corpus. On error the previous corpus stays in use.
+func LoadCorpus() (*Corpus, error) {
+	languages, err := data.Repo.Words().Languages()
+	if err != nil {
+		return nil, fmt.Errorf("loading languages: %w", err)
+	}
+
+	c := &Corpus{words: make(map[string][]string, len(languages))}
+	for _, language := range languages {
+		words, err := data.Repo.Words().All(language)
+		if err != nil {
+			return nil, fmt.Errorf("loading %s words: %w", language, err)
+		}
+		// Word files saved with decomposed accents still match what's typed
+		for i, w := range words {
+			words[i] = Compose(w)
+		}
+		c.words[language] = words
+	}
+	if len(c.words[DefaultLanguage]) == 0 {
+		return nil, fmt.Errorf("loading %s words: %w", DefaultLanguage, ErrNoWords)
+	}
+
+	corpus.Store(c)
+	return c, nil
+}
+
+// ReloadCorpus seeds the word files in cfg again, so words added to them since
+// startup are picked up, then loads the corpus. Words removed from a file stay
+// in the database.
+func ReloadCorpus(cfg config.Game) (*Corpus, error) {
+	if err := data.SeedWords(DefaultLanguage, cfg.WordsFile); err != nil {
+		return nil, fmt.Errorf("seeding %s: %w", cfg.WordsFile, err)
+	}
+	if err := data.SeedLanguages(cfg.WordsDir); err != nil {
+		return nil, fmt.Errorf("seeding %s: %w", cfg.WordsDir, err)
+	}
+
+	c, err := LoadCorpus()
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Loaded %d words in %d languages", c.Size(), len(c.words))
+	return c, nil
+}
+
+// Languages returns the codes that have words, sorted.
+func (c *Corpus) Languages() []string {
+	languages := make([]string, 0, len(c.words))
+	for language := range c.words {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Words returns the language's list, which callers must not modify, or
+// ErrNoLanguage if it has no words. It doesn't fall back to another
+// language, the run would be saved under one its words weren't from.
+func (c *Corpus) Words(language string) ([]string, error) {
+	if words := c.words[language]; len(words) > 0 {
+		return words, nil
+	}
+	return nil, fmt.Errorf("%s: %w", language, ErrNoLanguage)
+}
+
+// Size is the number of words over all languages.
+func (c *Corpus) Size() int {
+	n := 0
+	for _, words := range c.words {
+		n += len(words)
+	}
+	return n
+}
+
+// corpusWords returns the current corpus's words for language.
+func corpusWords(language string) ([]string, error) {
+	c := corpus.Load()
+	if c == nil {
+		return nil, ErrNoWords
+	}
+	return c.Words(language)
+}
@@ -0,0 +1,25 @@
+package util
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestCorpusWords(t *testing.T) {
+	c := &Corpus{words: map[string][]string{
+		DefaultLanguage: {"hello", "world"},
+		"nl":            {"hallo", "wereld"},
+		"de":            {},
+	}}
+
+	if words, err := c.Words("nl"); err != nil || !slices.Equal(words, []string{"hallo", "wereld"}) {
+		t.Errorf("Words(nl) = %q, %v", words, err)
+	}
+	// Not English, the run would be saved under the wrong language
+	for _, language := range []string{"de", "fr"} {
+		if words, err := c.Words(language); !errors.Is(err, ErrNoLanguage) {
+			t.Errorf("Words(%s) = %q, %v, want ErrNoLanguage", language, words, err)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package util
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"unicode"
+)
+
+// Difficulty says what generated text mixes in besides lowercase words. It's
+// saved with every score and raises its TP, see Multiplier.
+type Difficulty struct {
+	Capitals    bool
+	Punctuation bool
+	Numbers     bool
+	Symbols     bool
+}
+
+var (
+	// Easy is the plain lowercase word list, what every run used to be
+	Easy = Difficulty{}
+	// Normal reads like prose, with capitals and punctuation
+	Normal = Difficulty{Capitals: true, Punctuation: true}
+	// Hard adds numbers and symbols on top of Normal
+	Hard = Difficulty{Capitals: true, Punctuation: true, Numbers: true, Symbols: true}
+)
+
+// Difficulties lists the presets, in menu order.
+var Difficulties = []Difficulty{Easy, Normal, Hard}
+
+// Names of the toggles, in the order String joins them.
+var difficultyToggles = []string{"caps", "punctuation", "numbers", "symbols"}
+
+func (d Difficulty) toggles() []bool {
+	return []bool{d.Capitals, d.Punctuation, d.Numbers, d.Symbols}
+}
+
+// String is the preset name, or the toggles joined with "+" like
+// "caps+numbers" for other combinations.
+func (d Difficulty) String() string {
+	switch d {
+	case Easy:
+		return "easy"
+	case Normal:
+		return "normal"
+	case Hard:
+		return "hard"
+	}
+
+	var on []string
+	for i, enabled := range d.toggles() {
+		if enabled {
+			on = append(on, difficultyToggles[i])
+		}
+	}
+	return strings.Join(on, "+")
+}
+
+// ParseDifficulty accepts the preset names and toggle combinations like
+// "caps+punctuation", the format String writes.
+func ParseDifficulty(s string) (Difficulty, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "easy", "":
+		return Easy, true
+	case "normal", "medium":
+		return Normal, true
+	case "hard":
+		return Hard, true
+	}
+
+	var d Difficulty
+	for _, toggle := range strings.Split(s, "+") {
+		switch strings.TrimSpace(toggle) {
+		case "caps", "capitals", "capitalization":
+			d.Capitals = true
+		case "punctuation", "punct":
+			d.Punctuation = true
+		case "numbers", "digits":
+			d.Numbers = true
+		case "symbols":
+			d.Symbols = true
+		default:
+			return Easy, false
+		}
+	}
+	return d, true
+}
+
+// Multiplier scales TP so runs over harder text are worth more. Symbols and
+// numbers take the longest reaches off the home row, so they count the most.
+func (d Difficulty) Multiplier() float64 {
+	m := 1.0
+	if d.Capitals {
+		m += 0.05
+	}
+	if d.Punctuation {
+		m += 0.1
+	}
+	if d.Numbers {
+		m += 0.1
+	}
+	if d.Symbols {
+		m += 0.15
+	}
+	return m
+}
+
+var (
+	sentenceEnds = []string{".", ".", ".", "?", "!"}
+	clauseMarks  = []string{",", ",", ",", ";", ":"}
+	symbolWraps  = [][2]string{{"(", ")"}, {"[", "]"}, {"{", "}"}, {"\"", "\""}, {"'", "'"}, {"<", ">"}}
+	symbolMarks  = []string{"#", "@", "$", "&", "*", "~", "^"}
+	symbolJoins  = []string{"-", "_", "/", "=", "+", "|"}
+)
+
+// apply decorates words in place and returns them. Words are replaced by
+// numbers, wrapped in or joined with symbols, followed by punctuation and
+// capitalized at the start of each sentence, depending on the toggles. A
+// joined word gets its partner from pick, so there are as many words after as
+// before. Every choice comes from r, so a seeded r always decorates the same
+// way.
+func (d Difficulty) apply(r *rand.Rand, words []string, pick func() string) []string {
+	if d == Easy || len(words) == 0 {
+		return words
+	}
+	for i := range words {
+		if d.Numbers && r.Intn(6) == 0 {
+			words[i] = randomNumber(r)
+		}
+
+		if d.Symbols && r.Intn(5) == 0 {
+			switch r.Intn(3) {
+			case 0:
+				wrap := symbolWraps[r.Intn(len(symbolWraps))]
+				words[i] = wrap[0] + words[i] + wrap[1]
+			case 1:
+				words[i] = symbolMarks[r.Intn(len(symbolMarks))] + words[i]
+			default:
+				words[i] += symbolJoins[r.Intn(len(symbolJoins))] + pick()
+			}
+		}
+	}
+
+	sentenceStart := true
+	for i := range words {
+		if d.Capitals && sentenceStart {
+			words[i] = capitalize(words[i])
+		}
+		sentenceStart = false
+
+		if !d.Punctuation {
+			continue
+		}
+		switch {
+		case i == len(words)-1:
+			words[i] += sentenceEnds[r.Intn(len(sentenceEnds))]
+		case r.Intn(8) == 0:
+			words[i] += sentenceEnds[r.Intn(len(sentenceEnds))]
+			sentenceStart = true
+		case r.Intn(6) == 0:
+			words[i] += clauseMarks[r.Intn(len(clauseMarks))]
+		}
+	}
+
+	// Without punctuation there are no sentences, so capitalize some words
+	// to still exercise Shift
+	if d.Capitals && !d.Punctuation {
+		for i := 1; i < len(words); i++ {
+			if r.Intn(5) == 0 {
+				words[i] = capitalize(words[i])
+			}
+		}
+	}
+	return words
+}
+
+// randomNumber is a year, a small count or a decimal.
+func randomNumber(r *rand.Rand) string {
+	switch r.Intn(3) {
+	case 0:
+		return fmt.Sprint(1900 + r.Intn(130))
+	case 1:
+		return fmt.Sprint(r.Intn(100))
+	default:
+		return fmt.Sprintf("%d.%d", r.Intn(10), r.Intn(10))
+	}
+}
+
+// capitalize upper cases the first letter, skipping leading symbols.
+func capitalize(word string) string {
+	runes := []rune(word)
+	for i, c := range runes {
+		if unicode.IsLetter(c) {
+			runes[i] = unicode.ToUpper(c)
+			break
+		}
+	}
+	return string(runes)
+}
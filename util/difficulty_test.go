@@ -0,0 +1,59 @@
+package util
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestApplyKeepsWordCount(t *testing.T) {
+	plain := []string{"alpha", "beta", "gamma", "delta", "epsilon"}
+	for _, d := range []Difficulty{Easy, Normal, Hard, {Symbols: true}, {Numbers: true, Symbols: true}} {
+		t.Run(d.String(), func(t *testing.T) {
+			joined := 0
+			for seed := range int64(200) {
+				r := rand.New(rand.NewSource(seed))
+				pick := func() string { return plain[r.Intn(len(plain))] }
+				for _, n := range []int{1, 10, 25, 100} {
+					words := make([]string, n)
+					for i := range words {
+						words[i] = pick()
+					}
+
+					got := d.apply(r, words, pick)
+					if len(got) != n {
+						t.Fatalf("seed %d: %d words became %d: %q", seed, n, len(got), got)
+					}
+					for _, w := range got {
+						if w == "" || strings.ContainsRune(w, ' ') {
+							t.Fatalf("seed %d: word %q isn't one word", seed, w)
+						}
+						if slices.ContainsFunc(symbolJoins, func(j string) bool { return strings.Contains(w, j) }) {
+							joined++
+						}
+					}
+				}
+			}
+			if d.Symbols && joined == 0 {
+				t.Error("no words were joined by a symbol")
+			}
+		})
+	}
+}
+
+func TestApplyIsSeeded(t *testing.T) {
+	plain := []string{"alpha", "beta", "gamma", "delta", "epsilon"}
+	run := func() []string {
+		r := rand.New(rand.NewSource(7))
+		pick := func() string { return plain[r.Intn(len(plain))] }
+		words := make([]string, 50)
+		for i := range words {
+			words[i] = pick()
+		}
+		return Hard.apply(r, words, pick)
+	}
+	if a, b := run(), run(); !slices.Equal(a, b) {
+		t.Errorf("same seed gave\n%q\n%q", a, b)
+	}
+}
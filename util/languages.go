@@ -0,0 +1,50 @@
+package util
+
+import "strings"
+
+// DefaultLanguage is the code of the words_file list, and what players type
+// until they pick another language.
+const DefaultLanguage = "en"
+
+// languageNames are shown instead of codes for the languages we know. Packs
+// for other languages work too, they're just shown by code.
+var languageNames = map[string]string{
+	"en": "English",
+	"nl": "Nederlands",
+	"de": "Deutsch",
+	"fr": "Français",
+	"es": "Español",
+	"it": "Italiano",
+	"pt": "Português",
+	"sv": "Svenska",
+	"pl": "Polski",
+}
+
+// LanguageName is the language's own name for code, or the code itself.
+func LanguageName(code string) string {
+	if name, ok := languageNames[code]; ok {
+		return name
+	}
+	return code
+}
+
+// Languages returns the codes that have words to type, sorted.
+func Languages() []string {
+	c := corpus.Load()
+	if c == nil || len(c.words) == 0 {
+		return []string{DefaultLanguage}
+	}
+	return c.Languages()
+}
+
+// ParseLanguage accepts the code or name of a language with words, like
+// "nl" or "Nederlands".
+func ParseLanguage(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	for _, code := range Languages() {
+		if strings.EqualFold(s, code) || strings.EqualFold(s, LanguageName(code)) {
+			return code, true
+		}
+	}
+	return DefaultLanguage, false
+}
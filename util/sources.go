@@ -0,0 +1,191 @@
+package util
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Quotes and code snippets ship inside the binary, like the migrations.
+//
+//go:embed texts
+var textFiles embed.FS
+
+// Passage is one text to type.
+type Passage struct {
+	Text string
+	// Who said it or what language it is, empty for generated text
+	Attribution string
+	// What the text was generated with, Easy for quotes and code which are
+	// typed as written
+	Difficulty Difficulty
+	// Language code of the text, quotes and code are English
+	Language string
+	// Picks the text, the same seed and options always give the same passage
+	Seed int64
+}
+
+// TextSource produces the passages a single sentence run is typed against.
+type TextSource interface {
+	// Name is saved with scores, so every source has its own leaderboard
+	Name() string
+	// Passage picks or generates the text for seed. Generated sources use
+	// opts, the others ignore them.
+	Passage(seed int64, opts TextOptions) (Passage, error)
+}
+
+var (
+	WordSource  TextSource = wordSource{}
+	QuoteSource TextSource = &fileSource{name: "quotes", load: loadQuotes}
+	CodeSource  TextSource = &fileSource{name: "code", load: loadCode}
+)
+
+// Sources lists every text source, in menu order.
+var Sources = []TextSource{WordSource, QuoteSource, CodeSource}
+
+// ParseSource accepts names like "words", "quotes" and "code".
+func ParseSource(s string) (TextSource, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "words", "word", "dictionary":
+		return WordSource, true
+	case "quotes", "quote":
+		return QuoteSource, true
+	case "code", "snippets", "snippet":
+		return CodeSource, true
+	}
+	return WordSource, false
+}
+
+// SourceByName returns the source saved as name, WordSource for unknown names.
+func SourceByName(name string) TextSource {
+	for _, s := range Sources {
+		if s.Name() == name {
+			return s
+		}
+	}
+	return WordSource
+}
+
+// wordSource strings random dictionary words together, see
+// Generator.Sentence.
+type wordSource struct{}
+
+func (wordSource) Name() string { return "words" }
+
+func (wordSource) Passage(seed int64, opts TextOptions) (Passage, error) {
+	text, err := GetSentences(seed, opts)
+	if err != nil {
+		return Passage{}, err
+	}
+	return Passage{Text: text, Difficulty: opts.Difficulty, Language: opts.language(), Seed: seed}, nil
+}
+
+// fileSource picks passages from the bundled text files, loaded on first use.
+type fileSource struct {
+	name     string
+	load     func() ([]Passage, error)
+	mu       sync.Mutex
+	passages []Passage
+}
+
+func (f *fileSource) Name() string { return f.name }
+
+func (f *fileSource) Passage(seed int64, _ TextOptions) (Passage, error) {
+	passages, err := f.loaded()
+	if err != nil {
+		return Passage{}, err
+	}
+	passage := passages[rand.New(rand.NewSource(seed)).Intn(len(passages))]
+	passage.Seed = seed
+	return passage, nil
+}
+
+// loaded returns the passages, loading them if that hasn't worked yet.
+func (f *fileSource) loaded() ([]Passage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.passages != nil {
+		return f.passages, nil
+	}
+
+	passages, err := f.load()
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", f.name, err)
+	}
+	if len(passages) == 0 {
+		return nil, fmt.Errorf("no %s available", f.name)
+	}
+	f.passages = passages
+	return passages, nil
+}
+
+// loadQuotes reads texts/quotes.txt, one "quote -- author" per line.
+func loadQuotes() ([]Passage, error) {
+	file, err := textFiles.Open("texts/quotes.txt")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var quotes []Passage
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		text, author, _ := strings.Cut(line, " -- ")
+		quotes = append(quotes, Passage{Text: strings.TrimSpace(text), Attribution: strings.TrimSpace(author), Language: DefaultLanguage})
+	}
+	return quotes, scanner.Err()
+}
+
+// codeLanguages names the files in texts/code.
+var codeLanguages = map[string]string{
+	"go":         "Go",
+	"python":     "Python",
+	"javascript": "JavaScript",
+	"rust":       "Rust",
+	"sql":        "SQL",
+}
+
+// loadCode reads the snippets in texts/code, one file per language with
+// snippets separated by "---" lines. Indentation is kept, tabs become four
+// spaces so everyone types the same thing.
+func loadCode() ([]Passage, error) {
+	entries, err := fs.ReadDir(textFiles, "texts/code")
+	if err != nil {
+		return nil, err
+	}
+
+	var snippets []Passage
+	for _, entry := range entries {
+		lang := strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))
+		if name, ok := codeLanguages[lang]; ok {
+			lang = name
+		}
+
+		content, err := textFiles.ReadFile("texts/code/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		text := strings.ReplaceAll(string(content), "\t", "    ")
+		for _, snippet := range strings.Split(text, "\n---\n") {
+			// Trailing spaces can't be seen, so nobody should have to type them
+			lines := strings.Split(strings.Trim(snippet, "\n"), "\n")
+			for i, line := range lines {
+				lines[i] = strings.TrimRight(line, " ")
+			}
+			if snippet := strings.Join(lines, "\n"); snippet != "" {
+				snippets = append(snippets, Passage{Text: snippet, Attribution: lang, Language: DefaultLanguage})
+			}
+		}
+	}
+	return snippets, nil
+}
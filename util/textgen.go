@@ -1,49 +1,118 @@
 package util
 
 import (
-	"log"
+	"hash/fnv"
 	"math/rand"
-	"ssh-battle/data"
+	"sort"
 	"strings"
-	"time"
 )
 
-func GetSentences() string {
-	words, err := getWordsFromDB()
-	if err != nil {
-		log.Fatal(err)
-	}
+// TextOptions are what generated text is made of, besides its seed.
+type TextOptions struct {
+	// Language code of the words, DefaultLanguage if empty
+	Language   string
+	Difficulty Difficulty
+}
 
-	if len(words) == 0 {
-		log.Fatal("no words available from DB")
+func (o TextOptions) language() string {
+	if o.Language == "" {
+		return DefaultLanguage
 	}
+	return o.Language
+}
 
-	r := rand.New(rand.NewSource(time.Now().UnixNano())) // local rand.Rand instance
+// NewSeed picks a seed for text nobody has typed yet. Seeds are never 0, which
+// scores use for text that can't be generated again.
+func NewSeed() int64 {
+	// Short enough to read out or paste into a chat
+	return rand.Int63n(1_000_000_000) + 1
+}
 
-	length := r.Intn(5) + 10 // sentence length 4-9 words
-	sentenceWords := make([]string, length)
-	for j := range length {
-		sentenceWords[j] = words[r.Intn(len(words))]
+// DailySeed is the seed of a daily challenge, day being its YYYY-MM-DD date.
+// The same day always gives the same seed.
+func DailySeed(day string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("daily:" + day))
+	return int64(h.Sum64()%1_000_000_000) + 1
+}
+
+// Generator makes text from a seed. The same seed and options give the same
+// text as long as the word lists don't change, so a run can be typed again
+// and everyone in a battle gets the same words. A Generator isn't safe for
+// concurrent use, make one per run.
+type Generator struct {
+	Seed    int64
+	Options TextOptions
+	rng     *rand.Rand
+}
+
+func NewGenerator(seed int64, opts TextOptions) *Generator {
+	return &Generator{Seed: seed, Options: opts, rng: rand.New(rand.NewSource(seed))}
+}
+
+// GetSentences generates the sentence for seed, see Generator.Sentence.
+func GetSentences(seed int64, opts TextOptions) (string, error) {
+	return NewGenerator(seed, opts).Sentence()
+}
+
+// sentenceLength picks how many words a sentence has, 10-14.
+func (g *Generator) sentenceLength() int {
+	return g.rng.Intn(5) + 10
+}
+
+// Sentence is 10-14 random words with the difficulty applied.
+func (g *Generator) Sentence() (string, error) {
+	words, err := g.Words(g.sentenceLength())
+	if err != nil {
+		return "", err
 	}
-	sentence := strings.Join(sentenceWords, " ")
-	
-	return sentence
+	return strings.Join(words, " "), nil
 }
 
-func getWordsFromDB() ([]string, error) {
-	rows, err := data.DB.Query("SELECT word FROM words")
+// Words returns the next n uniformly random words with the difficulty
+// applied. Words joined by a symbol count as one.
+func (g *Generator) Words(n int) ([]string, error) {
+	words, err := corpusWords(g.Options.language())
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var DBWords []string
-	for rows.Next() {
-		var w string
-		if err := rows.Scan(&w); err != nil {
-			return nil, err
-		}
-		DBWords = append(DBWords, w)
+	pick := func() string { return words[g.rng.Intn(len(words))] }
+	picked := make([]string, n)
+	for j := range n {
+		picked[j] = pick()
+	}
+	return g.Options.Difficulty.apply(g.rng, picked, pick), nil
+}
+
+// WeightedSentence is Sentence with each word picked in proportion to
+// weight(word) instead of uniformly. The weights are worked out on the plain
+// words, the difficulty dresses them up after.
+func (g *Generator) WeightedSentence(weight func(word string) float64) (string, error) {
+	words, err := corpusWords(g.Options.language())
+	if err != nil {
+		return "", err
+	}
+
+	// Running totals, a word owns the range up to its total
+	cumulative := make([]float64, len(words))
+	total := 0.0
+	for i, w := range words {
+		total += max(weight(w), 0)
+		cumulative[i] = total
+	}
+	if total == 0 {
+		return g.Sentence()
+	}
+
+	pick := func() string {
+		i := sort.SearchFloat64s(cumulative, g.rng.Float64()*total)
+		return words[min(i, len(words)-1)]
+	}
+	length := g.sentenceLength()
+	sentenceWords := make([]string, length)
+	for j := range length {
+		sentenceWords[j] = pick()
 	}
-	return DBWords, nil
+	return strings.Join(g.Options.Difficulty.apply(g.rng, sentenceWords, pick), " "), nil
 }